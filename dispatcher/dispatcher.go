@@ -12,7 +12,7 @@ type TestCaseDispatcher struct {
 	// in is the channel for incoming test cases.
 	in chan *input.TestCase
 	// out is the channel for outgoing results.
-	out chan string
+	out chan Result
 	// pipeSize is the size of the channels in and out.
 	pipeSize int
 
@@ -21,6 +21,9 @@ type TestCaseDispatcher struct {
 	// poolSize is the number of workers in the pool.
 	poolSize int
 
+	// processor is the processor used by the workers to process the test cases.
+	processor Processor
+
 	log logger.Logger
 }
 
@@ -56,7 +59,7 @@ func WithDispatcherPipeSize(pipeSize int) TestCaseDispatcherOption {
 
 		if d.pipeSize > 0 {
 			d.in = make(chan *input.TestCase, d.pipeSize)
-			d.out = make(chan string, d.pipeSize)
+			d.out = make(chan Result, d.pipeSize)
 		}
 	}
 }
@@ -72,6 +75,15 @@ func WithDispatcherPoolSize(poolSize int) TestCaseDispatcherOption {
 	}
 }
 
+// WithDispatcherProcessor sets the processor used by the workers to process the test cases.
+//
+// If no processor is set, the workers use a grid processor with default options.
+func WithDispatcherProcessor(processor Processor) TestCaseDispatcherOption {
+	return func(d *TestCaseDispatcher) {
+		d.processor = processor
+	}
+}
+
 // WithDispatcherLogger sets the logger for the dispatcher.
 func WithDispatcherLogger(log logger.Logger) TestCaseDispatcherOption {
 	return func(d *TestCaseDispatcher) {
@@ -85,7 +97,7 @@ func (d *TestCaseDispatcher) Dispatch(testCase *input.TestCase) {
 }
 
 // Results returns the channel for outgoing results.
-func (d *TestCaseDispatcher) Results() <-chan string {
+func (d *TestCaseDispatcher) Results() <-chan Result {
 	return d.out
 }
 
@@ -104,12 +116,16 @@ func (d *TestCaseDispatcher) Stop(ctx context.Context) {
 
 // startHandlers starts the worker pool with the specified number of workers.
 func (d *TestCaseDispatcher) startHandlers(ctx context.Context) {
+	if d.processor == nil {
+		d.processor = NewGridProcessor()
+	}
+
 	d.pool = startPool(
 		ctx,
 		d.poolSize,
 		withHandlerIn(d.in),
 		withHandlerOut(d.out),
-		withHandlerProcessor(NewGridProcessor()),
+		withHandlerProcessor(d.processor),
 		withHandlerLogger(d.log),
 	)
 }
@@ -47,6 +47,7 @@ func TestNewTestCaseDispatcher(t *testing.T) {
 			assert.Equal(t, test.want.pipeSize, got.pipeSize)
 			assert.Equal(t, test.want.poolSize, got.poolSize)
 			assert.Equal(t, test.want.log, got.log)
+			assert.NotNil(t, got.processor)
 			if test.want.pipeSize > 0 {
 				assert.NotNil(t, got.in)
 				assert.NotNil(t, got.out)
@@ -127,6 +128,15 @@ func TestWithDispatcherPoolSize(t *testing.T) {
 	}
 }
 
+func TestWithDispatcherProcessor(t *testing.T) {
+	p := NewMockProcessor(t)
+
+	d := &TestCaseDispatcher{}
+
+	WithDispatcherProcessor(p)(d)
+	assert.Equal(t, p, d.processor)
+}
+
 func TestWithDispatcherLogger(t *testing.T) {
 	l := NewMockLogger(t)
 
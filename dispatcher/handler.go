@@ -13,11 +13,12 @@ type Processor interface {
 	// GetGrid returns a new pathfinder grid initialized with the provided rows, columns, and obstacles.
 	GetGrid(rows, cols int, obstacles ...pathfinder.Obstacle) *pathfinder.Grid
 
-	// GetPathfinder returns a new pathfinder initialized with the provided grid and heuristic function.
-	GetPathfinder(g *pathfinder.Grid, distance pathfinder.Heuristic) pathfinder.Pathfinder
+	// GetPathfinder returns a new pathfinder initialized with the provided grid, heuristic function and game rules.
+	GetPathfinder(g *pathfinder.Grid, distance pathfinder.Heuristic, rules pathfinder.Rules) pathfinder.Pathfinder
 
 	// Process processes the provided test case and returns the result.
-	Process(*input.TestCase) (string, error)
+	// The processing is stopped once the context is done.
+	Process(context.Context, *input.TestCase) (Result, error)
 }
 
 // testCaseHandler handles the processing of a single test case.
@@ -27,7 +28,7 @@ type testCaseHandler struct {
 	// in is the channel for incoming test cases.
 	in <-chan *input.TestCase
 	// out is the channel for outgoing results.
-	out chan<- string
+	out chan<- Result
 
 	// processor is the function that processes the test case.
 	processor Processor
@@ -64,7 +65,7 @@ func withHandlerIn(in <-chan *input.TestCase) testCaseHandlerOption {
 }
 
 // withHandlerOut sets the channel for outgoing results.
-func withHandlerOut(out chan<- string) testCaseHandlerOption {
+func withHandlerOut(out chan<- Result) testCaseHandlerOption {
 	return func(h *testCaseHandler) {
 		h.out = out
 	}
@@ -96,7 +97,7 @@ func (h *testCaseHandler) Run(ctx context.Context) {
 				h.log.Debug("stopping test case handler")
 				return
 			default:
-				result, err := h.processor.Process(testCase)
+				result, err := h.processor.Process(ctx, testCase)
 				if err != nil {
 					h.log.Error(err, "failed to process test case", "id", testCase.ID)
 					continue
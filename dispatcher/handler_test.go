@@ -26,13 +26,13 @@ func TestNewTestCaseHandler(t *testing.T) {
 			name: "valid options",
 			opts: []testCaseHandlerOption{
 				withHandlerIn(make(chan *input.TestCase, 3)),
-				withHandlerOut(make(chan string, 3)),
+				withHandlerOut(make(chan Result, 3)),
 				withHandlerLogger(NewMockLogger(t)),
 				withHandlerProcessor(NewMockProcessor(t)),
 			},
 			want: &testCaseHandler{
 				in:        make(chan *input.TestCase, 3),
-				out:       make(chan string, 3),
+				out:       make(chan Result, 3),
 				log:       NewMockLogger(t),
 				processor: NewMockProcessor(t),
 			},
@@ -64,34 +64,34 @@ func TestTestCaseHandler_Run(t *testing.T) {
 		on   func(processor *MockProcessor, logger *MockLogger)
 		ctx  context.Context
 		in   chan *input.TestCase
-		out  chan string
+		out  chan Result
 		tc   *input.TestCase
-		want string
+		want Result
 		err  error
 	}{
 		{
 			name: "valid test case",
 			on: func(processor *MockProcessor, logger *MockLogger) {
-				processor.EXPECT().Process(mock.AnythingOfType("*input.TestCase")).Return("result", nil)
+				processor.EXPECT().Process(mock.Anything, mock.AnythingOfType("*input.TestCase")).Return(Result{ID: 1, Text: "result"}, nil)
 			},
 			ctx:  context.Background(),
 			in:   make(chan *input.TestCase, 1),
-			out:  make(chan string, 1),
+			out:  make(chan Result, 1),
 			tc:   &input.TestCase{ID: 1},
-			want: "result",
+			want: Result{ID: 1, Text: "result"},
 			err:  nil,
 		},
 		{
 			name: "processor error",
 			on: func(processor *MockProcessor, logger *MockLogger) {
-				processor.EXPECT().Process(mock.AnythingOfType("*input.TestCase")).Return("", assert.AnError)
+				processor.EXPECT().Process(mock.Anything, mock.AnythingOfType("*input.TestCase")).Return(Result{}, assert.AnError)
 				logger.EXPECT().Error(assert.AnError, "failed to process test case", "id", 1)
 			},
 			ctx:  context.Background(),
 			in:   make(chan *input.TestCase, 1),
-			out:  make(chan string, 1),
+			out:  make(chan Result, 1),
 			tc:   &input.TestCase{ID: 1},
-			want: "",
+			want: Result{},
 			err:  assert.AnError,
 		},
 		{
@@ -106,9 +106,9 @@ func TestTestCaseHandler_Run(t *testing.T) {
 				return ctx
 			}(),
 			in:   make(chan *input.TestCase, 1),
-			out:  make(chan string, 1),
+			out:  make(chan Result, 1),
 			tc:   &input.TestCase{ID: 1},
-			want: "",
+			want: Result{},
 			err:  context.Canceled,
 		},
 	}
@@ -159,7 +159,7 @@ func TestTestCaseHandler_Run_Closed_input_channel(t *testing.T) {
 			return in
 		}(),
 		),
-		withHandlerOut(make(chan string, 1)),
+		withHandlerOut(make(chan Result, 1)),
 		withHandlerLogger(l),
 		withHandlerProcessor(p),
 	)
@@ -3,6 +3,8 @@
 package dispatcher
 
 import (
+	context "context"
+
 	input "github.com/laonix/hopping-race-tracks/input"
 	mock "github.com/stretchr/testify/mock"
 
@@ -82,13 +84,13 @@ func (_c *MockProcessor_GetGrid_Call) RunAndReturn(run func(int, int, ...pathfin
 	return _c
 }
 
-// GetPathfinder provides a mock function with given fields: g, distance
-func (_m *MockProcessor) GetPathfinder(g *pathfinder.Grid, distance pathfinder.Heuristic) pathfinder.Pathfinder {
-	ret := _m.Called(g, distance)
+// GetPathfinder provides a mock function with given fields: g, distance, rules
+func (_m *MockProcessor) GetPathfinder(g *pathfinder.Grid, distance pathfinder.Heuristic, rules pathfinder.Rules) pathfinder.Pathfinder {
+	ret := _m.Called(g, distance, rules)
 
 	var r0 pathfinder.Pathfinder
-	if rf, ok := ret.Get(0).(func(*pathfinder.Grid, pathfinder.Heuristic) pathfinder.Pathfinder); ok {
-		r0 = rf(g, distance)
+	if rf, ok := ret.Get(0).(func(*pathfinder.Grid, pathfinder.Heuristic, pathfinder.Rules) pathfinder.Pathfinder); ok {
+		r0 = rf(g, distance, rules)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(pathfinder.Pathfinder)
@@ -106,13 +108,14 @@ type MockProcessor_GetPathfinder_Call struct {
 // GetPathfinder is a helper method to define mock.On call
 //   - g *pathfinder.Grid
 //   - distance pathfinder.Heuristic
-func (_e *MockProcessor_Expecter) GetPathfinder(g interface{}, distance interface{}) *MockProcessor_GetPathfinder_Call {
-	return &MockProcessor_GetPathfinder_Call{Call: _e.mock.On("GetPathfinder", g, distance)}
+//   - rules pathfinder.Rules
+func (_e *MockProcessor_Expecter) GetPathfinder(g interface{}, distance interface{}, rules interface{}) *MockProcessor_GetPathfinder_Call {
+	return &MockProcessor_GetPathfinder_Call{Call: _e.mock.On("GetPathfinder", g, distance, rules)}
 }
 
-func (_c *MockProcessor_GetPathfinder_Call) Run(run func(g *pathfinder.Grid, distance pathfinder.Heuristic)) *MockProcessor_GetPathfinder_Call {
+func (_c *MockProcessor_GetPathfinder_Call) Run(run func(g *pathfinder.Grid, distance pathfinder.Heuristic, rules pathfinder.Rules)) *MockProcessor_GetPathfinder_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(*pathfinder.Grid), args[1].(pathfinder.Heuristic))
+		run(args[0].(*pathfinder.Grid), args[1].(pathfinder.Heuristic), args[2].(pathfinder.Rules))
 	})
 	return _c
 }
@@ -122,28 +125,28 @@ func (_c *MockProcessor_GetPathfinder_Call) Return(_a0 pathfinder.Pathfinder) *M
 	return _c
 }
 
-func (_c *MockProcessor_GetPathfinder_Call) RunAndReturn(run func(*pathfinder.Grid, pathfinder.Heuristic) pathfinder.Pathfinder) *MockProcessor_GetPathfinder_Call {
+func (_c *MockProcessor_GetPathfinder_Call) RunAndReturn(run func(*pathfinder.Grid, pathfinder.Heuristic, pathfinder.Rules) pathfinder.Pathfinder) *MockProcessor_GetPathfinder_Call {
 	_c.Call.Return(run)
 	return _c
 }
 
-// Process provides a mock function with given fields: _a0
-func (_m *MockProcessor) Process(_a0 *input.TestCase) (string, error) {
-	ret := _m.Called(_a0)
+// Process provides a mock function with given fields: _a0, _a1
+func (_m *MockProcessor) Process(_a0 context.Context, _a1 *input.TestCase) (Result, error) {
+	ret := _m.Called(_a0, _a1)
 
-	var r0 string
+	var r0 Result
 	var r1 error
-	if rf, ok := ret.Get(0).(func(*input.TestCase) (string, error)); ok {
-		return rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, *input.TestCase) (Result, error)); ok {
+		return rf(_a0, _a1)
 	}
-	if rf, ok := ret.Get(0).(func(*input.TestCase) string); ok {
-		r0 = rf(_a0)
+	if rf, ok := ret.Get(0).(func(context.Context, *input.TestCase) Result); ok {
+		r0 = rf(_a0, _a1)
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Get(0).(Result)
 	}
 
-	if rf, ok := ret.Get(1).(func(*input.TestCase) error); ok {
-		r1 = rf(_a0)
+	if rf, ok := ret.Get(1).(func(context.Context, *input.TestCase) error); ok {
+		r1 = rf(_a0, _a1)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -157,24 +160,25 @@ type MockProcessor_Process_Call struct {
 }
 
 // Process is a helper method to define mock.On call
-//   - _a0 *input.TestCase
-func (_e *MockProcessor_Expecter) Process(_a0 interface{}) *MockProcessor_Process_Call {
-	return &MockProcessor_Process_Call{Call: _e.mock.On("Process", _a0)}
+//   - _a0 context.Context
+//   - _a1 *input.TestCase
+func (_e *MockProcessor_Expecter) Process(_a0 interface{}, _a1 interface{}) *MockProcessor_Process_Call {
+	return &MockProcessor_Process_Call{Call: _e.mock.On("Process", _a0, _a1)}
 }
 
-func (_c *MockProcessor_Process_Call) Run(run func(_a0 *input.TestCase)) *MockProcessor_Process_Call {
+func (_c *MockProcessor_Process_Call) Run(run func(_a0 context.Context, _a1 *input.TestCase)) *MockProcessor_Process_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(*input.TestCase))
+		run(args[0].(context.Context), args[1].(*input.TestCase))
 	})
 	return _c
 }
 
-func (_c *MockProcessor_Process_Call) Return(_a0 string, _a1 error) *MockProcessor_Process_Call {
+func (_c *MockProcessor_Process_Call) Return(_a0 Result, _a1 error) *MockProcessor_Process_Call {
 	_c.Call.Return(_a0, _a1)
 	return _c
 }
 
-func (_c *MockProcessor_Process_Call) RunAndReturn(run func(*input.TestCase) (string, error)) *MockProcessor_Process_Call {
+func (_c *MockProcessor_Process_Call) RunAndReturn(run func(context.Context, *input.TestCase) (Result, error)) *MockProcessor_Process_Call {
 	_c.Call.Return(run)
 	return _c
 }
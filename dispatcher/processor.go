@@ -1,7 +1,9 @@
 package dispatcher
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	"github.com/pkg/errors"
 
@@ -10,11 +12,102 @@ import (
 )
 
 // gridProcessor is an implementation of the Processor interface for the dispatcher.
-type gridProcessor struct{}
+type gridProcessor struct {
+	// algorithm is the path finding algorithm used to solve the test cases.
+	algorithm pathfinder.Algorithm
+	// rules are the game rules used unless a test case overrides them.
+	rules pathfinder.Rules
+	// hops indicates whether the hops of the found solution are included in the result.
+	hops bool
+	// field indicates whether the distance field of the track is included in the result.
+	field bool
+	// count indicates whether the number of the optimal races is included in the result.
+	count bool
+	// list is the maximal number of the optimal races listed in the result.
+	list int
+	// stats indicates whether the statistics of the search are included in the result text.
+	stats bool
+	// searchOptions are the limits of the search of each test case.
+	searchOptions []pathfinder.SearchOption
+}
+
+// GridProcessorOption provides a way to configure the grid processor.
+type GridProcessorOption func(p *gridProcessor)
+
+// NewGridProcessor creates a new processor for the dispatcher with the provided options.
+func NewGridProcessor(opts ...GridProcessorOption) Processor {
+	p := &gridProcessor{
+		algorithm: pathfinder.AlgorithmAStar,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// WithProcessorAlgorithm sets the path finding algorithm used to solve the test cases.
+func WithProcessorAlgorithm(algorithm pathfinder.Algorithm) GridProcessorOption {
+	return func(p *gridProcessor) {
+		p.algorithm = algorithm
+	}
+}
+
+// WithProcessorRules sets the game rules used unless a test case overrides them.
+func WithProcessorRules(rules pathfinder.Rules) GridProcessorOption {
+	return func(p *gridProcessor) {
+		p.rules = rules
+	}
+}
 
-// NewGridProcessor creates a new processor for the dispatcher.
-func NewGridProcessor() Processor {
-	return &gridProcessor{}
+// WithProcessorHops sets whether the hops of the found solution are included in the result.
+func WithProcessorHops(hops bool) GridProcessorOption {
+	return func(p *gridProcessor) {
+		p.hops = hops
+	}
+}
+
+// WithProcessorField sets whether the distance field of the track
+// (i.e., the minimal number of hops to land on each cell) is included in the result
+// together with the cells that can never be landed on.
+func WithProcessorField(field bool) GridProcessorOption {
+	return func(p *gridProcessor) {
+		p.field = field
+	}
+}
+
+// WithProcessorCount sets whether the number of the optimal races
+// (i.e., whether the optimal race is unique) is included in the result.
+func WithProcessorCount(count bool) GridProcessorOption {
+	return func(p *gridProcessor) {
+		p.count = count
+	}
+}
+
+// WithProcessorList sets the maximal number of the optimal races listed in the result.
+// Listing the races includes their number in the result as well.
+func WithProcessorList(list int) GridProcessorOption {
+	return func(p *gridProcessor) {
+		p.list = list
+	}
+}
+
+// WithProcessorStats sets whether the statistics of the search are included in the result text.
+// The statistics are available in the result regardless of this option.
+func WithProcessorStats(stats bool) GridProcessorOption {
+	return func(p *gridProcessor) {
+		p.stats = stats
+	}
+}
+
+// WithProcessorSearchOptions sets the limits of the search of each test case
+// (e.g., the maximal number of expanded states or the search timeout).
+func WithProcessorSearchOptions(opts ...pathfinder.SearchOption) GridProcessorOption {
+	return func(p *gridProcessor) {
+		// the options are appended to per test case, so they must not share the backing array of the caller
+		p.searchOptions = append([]pathfinder.SearchOption(nil), opts...)
+	}
 }
 
 // GetGrid returns a new pathfinder grid initialized with the provided rows, columns, and obstacles.
@@ -22,36 +115,206 @@ func (p *gridProcessor) GetGrid(rows, cols int, obstacles ...pathfinder.Obstacle
 	return pathfinder.NewGrid(rows, cols, obstacles...)
 }
 
-// GetPathfinder returns a new pathfinder initialized with the provided grid and heuristic function.
-func (p *gridProcessor) GetPathfinder(g *pathfinder.Grid, distance pathfinder.Heuristic) pathfinder.Pathfinder {
-	return pathfinder.NewGridPathfinder(g, distance)
+// GetPathfinder returns a new pathfinder initialized with the provided grid, heuristic function and game rules.
+//
+// The type of the pathfinder depends on the algorithm the processor is configured with;
+// the heuristic function is ignored by the algorithms that do not rely on it.
+// If the algorithm is unknown, nil is returned.
+func (p *gridProcessor) GetPathfinder(g *pathfinder.Grid, distance pathfinder.Heuristic, rules pathfinder.Rules) pathfinder.Pathfinder {
+	return newPathfinder(p.algorithm, g, distance, rules)
+}
+
+// newPathfinder returns a new pathfinder of the given algorithm
+// initialized with the provided grid, heuristic function and game rules.
+// If the algorithm is unknown, nil is returned.
+func newPathfinder(algorithm pathfinder.Algorithm, g *pathfinder.Grid, distance pathfinder.Heuristic, rules pathfinder.Rules) pathfinder.Pathfinder {
+	switch algorithm {
+	case pathfinder.AlgorithmAStar:
+		return pathfinder.NewGridPathfinder(g, distance, rules)
+	case pathfinder.AlgorithmBFS:
+		return pathfinder.NewBreadthFirstPathfinder(g, rules)
+	case pathfinder.AlgorithmIDAStar:
+		return pathfinder.NewIterativeDeepeningPathfinder(g, distance, rules)
+	case pathfinder.AlgorithmBidirectional:
+		return pathfinder.NewBidirectionalPathfinder(g, distance, rules)
+	default:
+		return nil
+	}
 }
 
 // Process processes a single test case and returns the result.
 //
-// It initializes a new pathfinder with the grid and obstacles from the test case,
-// finds the path from the start to the end cell, and returns the string representation of the result.
-func (p *gridProcessor) Process(in *input.TestCase) (string, error) {
+// It initializes a new pathfinder with the grid, obstacles and game rules from the test case
+// (of the algorithm selected by the test case, if any),
+// finds the path from the start to the end cell, and returns the result holding its string representation
+// and the statistics of the search.
+// If the test case has start or finish zones, the result reports the start and finish cells of the best race.
+//
+// If the search exceeds its limits, the result reports the reason and the best partial race found.
+// If the context is done, the search is stopped and an error is returned.
+func (p *gridProcessor) Process(ctx context.Context, in *input.TestCase) (Result, error) {
 	if in == nil {
-		return "", errors.New("test case must be provided")
+		return Result{}, errors.New("test case must be provided")
 	}
 
 	g := p.GetGrid(in.GridRows, in.GridCols, getObstacles(in.Obstacles)...)
 	if g == nil {
-		return "", errors.New("failed to create grid")
+		return Result{}, errors.New("failed to create grid")
+	}
+	if len(in.Terrain) > 0 {
+		if g = g.WithTerrain(getTerrain(in.Terrain)...); g == nil {
+			return Result{}, errors.New("invalid test case terrain")
+		}
+	}
+
+	rules, err := p.rules.Apply(in.Rules)
+	if err != nil {
+		return Result{}, errors.Wrap(err, "invalid test case rules")
+	}
+
+	pf := p.GetPathfinder(g, pathfinder.VelocityDistance, rules)
+	if in.Algorithm != "" {
+		algorithm, err := pathfinder.ParseAlgorithm(in.Algorithm)
+		if err != nil {
+			return Result{}, errors.Wrap(err, "invalid test case algorithm")
+		}
+
+		pf = newPathfinder(algorithm, g, pathfinder.VelocityDistance, rules)
+	}
+	if pf == nil {
+		return Result{}, errors.New("failed to create pathfinder")
+	}
+
+	starts := getStarts(g, in)
+
+	// the processor is shared by the workers, so each test case gets its own slice of the search options
+	stats := &pathfinder.SearchStats{}
+	opts := make([]pathfinder.SearchOption, 0, len(p.searchOptions)+1)
+	opts = append(append(opts, p.searchOptions...), pathfinder.WithStats(stats))
+
+	solution, err := pf.FindPath(ctx, starts, getFinish(in), opts...)
+
+	var aborted *pathfinder.AbortedError
+	if errors.As(err, &aborted) && ctx.Err() == nil {
+		return p.result(in, abortedResult(in, aborted), stats), nil
+	}
+	if err != nil {
+		return Result{}, errors.Wrap(err, "failed to find path")
+	}
+
+	var text string
+	switch {
+	case solution == nil:
+		text = fmt.Sprintf("Test case #%d: No solution.", in.ID)
+	case len(in.StartZones) > 0 || len(in.FinishZones) > 0:
+		text = fmt.Sprintf("Test case #%d: Optimal solution takes %s from (%d,%d) to %s.",
+			in.ID, raceLength(g, solution), solution.Start.X, solution.Start.Y, solution.Finish)
+	default:
+		text = fmt.Sprintf("Test case #%d: Optimal solution takes %s.", in.ID, raceLength(g, solution))
+	}
+
+	if p.hops && solution != nil {
+		text += "\n" + indent(solution.String())
+	}
+
+	if (p.count || p.list > 0) && solution != nil {
+		races, err := pathfinder.CountOptimalRaces(ctx, g, starts, getFinish(in), rules, p.list, p.searchOptions...)
+		if err != nil && (!errors.As(err, &aborted) || ctx.Err() != nil) {
+			return Result{}, errors.Wrap(err, "failed to count optimal races")
+		}
+
+		text += "\n" + indent(optimalRaces(g, races, err))
+	}
+
+	if p.field {
+		field, err := pathfinder.NewDistanceField(g, starts, rules)
+		if err != nil {
+			return Result{}, errors.Wrap(err, "failed to calculate distance field")
+		}
+
+		text += "\n" + indent(field.String()) + "\n" + indent(unreachableCells(field))
+	}
+
+	return p.result(in, text, stats), nil
+}
+
+// result returns the result of the test case with the given report and search statistics,
+// adding the statistics to the report if requested.
+func (p *gridProcessor) result(in *input.TestCase, text string, stats *pathfinder.SearchStats) Result {
+	if p.stats {
+		text += "\n" + indent("stats: "+stats.String())
+	}
+
+	return Result{
+		ID:    in.ID,
+		Text:  text,
+		Stats: stats,
+	}
+}
+
+// raceLength returns the number of hops of the race,
+// followed by its cost if the grid has terrain.
+func raceLength(g *pathfinder.Grid, solution *pathfinder.Solution) string {
+	if g.Weighted() {
+		return fmt.Sprintf("%d hops at cost %d", solution.Len(), solution.Cost)
 	}
 
-	pf := p.GetPathfinder(g, pathfinder.ChebyshevDistance)
+	return fmt.Sprintf("%d hops", solution.Len())
+}
+
+// abortedResult returns the result of the test case whose search has been aborted.
+func abortedResult(in *input.TestCase, aborted *pathfinder.AbortedError) string {
+	result := fmt.Sprintf("Test case #%d: Search aborted (%s).", in.ID, aborted.Reason)
+
+	if partial := aborted.Partial; partial != nil {
+		result += fmt.Sprintf(" Best partial race takes %d hops from (%d,%d) to %s.",
+			partial.Len(), partial.Start.X, partial.Start.Y, partial.Finish)
+	}
+
+	return result
+}
+
+// optimalRaces returns the report on the optimal races of a test case
+// or on the reason of aborting their search.
+//
+// The races are counted by the number of hops, so on a grid with terrain they are reported
+// as the hop-optimal ones rather than the cheapest ones the optimal solution is.
+func optimalRaces(g *pathfinder.Grid, races *pathfinder.OptimalRaces, err error) string {
+	name := "optimal race"
+	if g.Weighted() {
+		name = "hop-optimal race"
+	}
 
-	path, err := pf.FindPath(getCell(in.Start.X, in.Start.Y), getCell(in.End.X, in.End.Y))
 	if err != nil {
-		return "", errors.Wrap(err, "failed to find path")
+		return fmt.Sprintf("%ss: %s", name, err)
 	}
-	if path != nil {
-		return fmt.Sprintf("Test case #%d: Optimal solution takes %d hops.", in.ID, path[len(path)-1].GCost), nil
-	} else {
-		return fmt.Sprintf("Test case #%d: No solution.", in.ID), nil
+
+	report := fmt.Sprintf("%ss: %s", name, races.Count)
+	for i, race := range races.Races {
+		report += fmt.Sprintf("\n%s %d:\n%s", name, i+1, indent(race.String()))
 	}
+
+	return report
+}
+
+// unreachableCells returns the report on the cells of the distance field that can never be landed on.
+func unreachableCells(field *pathfinder.DistanceField) string {
+	positions := field.Unreachable()
+	if len(positions) == 0 {
+		return "unreachable cells: none"
+	}
+
+	cells := make([]string, 0, len(positions))
+	for _, p := range positions {
+		cells = append(cells, p.String())
+	}
+
+	return "unreachable cells: " + strings.Join(cells, " ")
+}
+
+// indent prefixes each line of the provided text with two spaces.
+func indent(text string) string {
+	return "  " + strings.ReplaceAll(text, "\n", "\n  ")
 }
 
 // getCell returns a new pathfinder cell with the provided coordinates.
@@ -59,18 +322,85 @@ func getCell(x, y int) *pathfinder.Cell {
 	return &pathfinder.Cell{X: x, Y: y}
 }
 
+// getStarts returns the start cells of the race described by the provided test case:
+// the start cell together with the available cells of the start zones.
+func getStarts(g *pathfinder.Grid, in *input.TestCase) []*pathfinder.Cell {
+	starts := []*pathfinder.Cell{getCell(in.Start.X, in.Start.Y)}
+
+	for _, c := range getZoneCells(in.StartZones) {
+		if g.IsAvailable(c.X, c.Y) {
+			starts = append(starts, c)
+		}
+	}
+
+	return starts
+}
+
+// getFinish returns the finish of the race described by the provided test case:
+// either the finish line to cross or the end cell to land on,
+// together with the cells of the finish zones to land on.
+func getFinish(in *input.TestCase) pathfinder.Goal {
+	zones := getZoneCells(in.FinishZones)
+
+	if in.FinishLine != nil {
+		line := pathfinder.NewFinishLine(in.FinishLine.From.X, in.FinishLine.From.Y, in.FinishLine.To.X, in.FinishLine.To.Y)
+		if len(zones) == 0 {
+			return line
+		}
+
+		return pathfinder.Goals{line, pathfinder.NewLandingGoal(zones...)}
+	}
+
+	return pathfinder.NewLandingGoal(append([]*pathfinder.Cell{getCell(in.End.X, in.End.Y)}, zones...)...)
+}
+
+// getZoneCells returns new pathfinder cells covering the provided input areas.
+func getZoneCells(zones []input.Area) []*pathfinder.Cell {
+	var cells []*pathfinder.Cell
+
+	for _, z := range zones {
+		for y := z.Y1; y <= z.Y2; y++ {
+			for x := z.X1; x <= z.X2; x++ {
+				cells = append(cells, getCell(x, y))
+			}
+		}
+	}
+
+	return cells
+}
+
 // getObstacles returns a slice of pathfinder obstacles from the provided input obstacles.
 func getObstacles(inputObstacles []input.Obstacle) []pathfinder.Obstacle {
 	var obstacles []pathfinder.Obstacle
 
 	for _, o := range inputObstacles {
 		obstacles = append(obstacles, pathfinder.Obstacle{
-			X1: o.X1,
-			X2: o.X2,
-			Y1: o.Y1,
-			Y2: o.Y2,
+			X1:         o.X1,
+			X2:         o.X2,
+			Y1:         o.Y1,
+			Y2:         o.Y2,
+			ActiveFrom: o.ActiveFrom,
+			ActiveFor:  o.ActiveFor,
+			Period:     o.Period,
 		})
 	}
 
 	return obstacles
 }
+
+// getTerrain returns a slice of pathfinder terrain from the provided input terrain.
+func getTerrain(inputTerrain []input.Terrain) []pathfinder.Terrain {
+	terrain := make([]pathfinder.Terrain, 0, len(inputTerrain))
+
+	for _, t := range inputTerrain {
+		terrain = append(terrain, pathfinder.Terrain{
+			X1:   t.X1,
+			X2:   t.X2,
+			Y1:   t.Y1,
+			Y2:   t.Y2,
+			Cost: t.Cost,
+		})
+	}
+
+	return terrain
+}
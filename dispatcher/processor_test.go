@@ -1,10 +1,13 @@
 package dispatcher
 
 import (
+	"context"
+	"sync"
 	"testing"
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/laonix/hopping-race-tracks/input"
 	"github.com/laonix/hopping-race-tracks/pathfinder"
@@ -20,11 +23,39 @@ func TestNewGridProcessor(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			NewGridProcessor()
+			assert.NotNil(t, NewGridProcessor())
 		})
 	}
 }
 
+func TestWithProcessorAlgorithm(t *testing.T) {
+	p := &gridProcessor{}
+
+	WithProcessorAlgorithm(pathfinder.AlgorithmBFS)(p)
+	assert.Equal(t, pathfinder.AlgorithmBFS, p.algorithm)
+}
+
+func TestWithProcessorRules(t *testing.T) {
+	p := &gridProcessor{}
+
+	WithProcessorRules(pathfinder.Rules{MaxSpeed: 5})(p)
+	assert.Equal(t, pathfinder.Rules{MaxSpeed: 5}, p.rules)
+}
+
+func TestWithProcessorHops(t *testing.T) {
+	p := &gridProcessor{}
+
+	WithProcessorHops(true)(p)
+	assert.True(t, p.hops)
+}
+
+func TestWithProcessorField(t *testing.T) {
+	p := &gridProcessor{}
+
+	WithProcessorField(true)(p)
+	assert.True(t, p.field)
+}
+
 func TestGridProcessor_GetGrid(t *testing.T) {
 	type input struct {
 		rows      int
@@ -35,7 +66,9 @@ func TestGridProcessor_GetGrid(t *testing.T) {
 	tests := []struct {
 		name string
 		in   input
-		want *pathfinder.Grid
+		// occupied holds the cells covered by the obstacles, while the other cells of the grid are available
+		occupied []pathfinder.Position
+		wantNil  bool
 	}{
 		{
 			name: "valid grid",
@@ -46,27 +79,20 @@ func TestGridProcessor_GetGrid(t *testing.T) {
 					{X1: 1, Y1: 1, X2: 1, Y2: 1},
 				},
 			},
-			want: &pathfinder.Grid{
-				Rows: 3,
-				Cols: 3,
-				Cells: map[int]map[int]*pathfinder.Cell{
-					0: {
-						0: {X: 0, Y: 0, Available: true},
-						1: {X: 1, Y: 0, Available: true},
-						2: {X: 2, Y: 0, Available: true},
-					},
-					1: {
-						0: {X: 0, Y: 1, Available: true},
-						1: {X: 1, Y: 1, Available: false},
-						2: {X: 2, Y: 1, Available: true},
-					},
-					2: {
-						0: {X: 0, Y: 2, Available: true},
-						1: {X: 1, Y: 2, Available: true},
-						2: {X: 2, Y: 2, Available: true},
-					},
+			occupied: []pathfinder.Position{{X: 1, Y: 1}},
+		},
+		{
+			name: "obstacles clamped to the edges",
+			in: input{
+				rows: 3,
+				cols: 4,
+				obstacles: []pathfinder.Obstacle{
+					{X1: -2, Y1: 2, X2: 0, Y2: 5},
+					{X1: 3, Y1: -1, X2: 7, Y2: 0},
+					{X1: 5, Y1: 1, X2: 6, Y2: 1},
 				},
 			},
+			occupied: []pathfinder.Position{{X: 0, Y: 2}, {X: 3, Y: 0}},
 		},
 		{
 			name: "empty grid",
@@ -74,7 +100,7 @@ func TestGridProcessor_GetGrid(t *testing.T) {
 				rows: 0,
 				cols: 0,
 			},
-			want: nil,
+			wantNil: true,
 		},
 		{
 			name: "invalid input",
@@ -82,7 +108,7 @@ func TestGridProcessor_GetGrid(t *testing.T) {
 				rows: -1,
 				cols: -1,
 			},
-			want: nil,
+			wantNil: true,
 		},
 	}
 	for _, test := range tests {
@@ -90,38 +116,36 @@ func TestGridProcessor_GetGrid(t *testing.T) {
 			p := NewGridProcessor()
 
 			got := p.GetGrid(test.in.rows, test.in.cols, test.in.obstacles...)
-			assert.Equal(t, test.want, got)
+			if test.wantNil {
+				assert.Nil(t, got)
+				return
+			}
+
+			require.NotNil(t, got)
+			assert.Equal(t, test.in.rows, got.Rows)
+			assert.Equal(t, test.in.cols, got.Cols)
+
+			occupied := make(map[pathfinder.Position]bool)
+			for _, c := range test.occupied {
+				occupied[c] = true
+			}
+			for y := 0; y < got.Rows; y++ {
+				for x := 0; x < got.Cols; x++ {
+					assert.Equal(t, !occupied[pathfinder.Position{X: x, Y: y}], got.IsAvailable(x, y), "cell (%d,%d)", x, y)
+				}
+			}
 		})
 	}
 }
 
 func TestGridProcessor_GetPathfinder(t *testing.T) {
 	type input struct {
-		grid *pathfinder.Grid
-		h    pathfinder.Heuristic
+		algorithm pathfinder.Algorithm
+		grid      *pathfinder.Grid
+		h         pathfinder.Heuristic
 	}
 
-	grid := &pathfinder.Grid{
-		Rows: 3,
-		Cols: 3,
-		Cells: map[int]map[int]*pathfinder.Cell{
-			0: {
-				0: {X: 0, Y: 0, Available: true},
-				1: {X: 1, Y: 0, Available: true},
-				2: {X: 2, Y: 0, Available: true},
-			},
-			1: {
-				0: {X: 0, Y: 1, Available: true},
-				1: {X: 1, Y: 1, Available: false},
-				2: {X: 2, Y: 1, Available: true},
-			},
-			2: {
-				0: {X: 0, Y: 2, Available: true},
-				1: {X: 1, Y: 2, Available: true},
-				2: {X: 2, Y: 2, Available: true},
-			},
-		},
-	}
+	grid := pathfinder.NewGrid(3, 3, pathfinder.Obstacle{X1: 1, Y1: 1, X2: 1, Y2: 1})
 
 	tests := []struct {
 		name string
@@ -139,6 +163,50 @@ func TestGridProcessor_GetPathfinder(t *testing.T) {
 				Heuristic: pathfinder.ChebyshevDistance,
 			},
 		},
+		{
+			name: "valid breadth-first pathfinder",
+			in: input{
+				algorithm: pathfinder.AlgorithmBFS,
+				grid:      grid,
+			},
+			want: &pathfinder.BreadthFirstPathfinder{
+				Grid: grid,
+			},
+		},
+		{
+			name: "valid iterative deepening pathfinder",
+			in: input{
+				algorithm: pathfinder.AlgorithmIDAStar,
+				grid:      grid,
+				h:         pathfinder.ChebyshevDistance,
+			},
+			want: &pathfinder.IterativeDeepeningPathfinder{
+				Grid:      grid,
+				Heuristic: pathfinder.ChebyshevDistance,
+				TableSize: pathfinder.DefaultTableSize,
+			},
+		},
+		{
+			name: "valid bidirectional pathfinder",
+			in: input{
+				algorithm: pathfinder.AlgorithmBidirectional,
+				grid:      grid,
+				h:         pathfinder.ChebyshevDistance,
+			},
+			want: &pathfinder.BidirectionalPathfinder{
+				Grid:      grid,
+				Heuristic: pathfinder.ChebyshevDistance,
+			},
+		},
+		{
+			name: "unknown algorithm",
+			in: input{
+				algorithm: "dfs",
+				grid:      grid,
+				h:         pathfinder.ChebyshevDistance,
+			},
+			want: nil,
+		},
 		{
 			name: "invalid grid",
 			in: input{
@@ -160,10 +228,14 @@ func TestGridProcessor_GetPathfinder(t *testing.T) {
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			p := NewGridProcessor()
+			if test.in.algorithm != "" {
+				p = NewGridProcessor(WithProcessorAlgorithm(test.in.algorithm))
+			}
 
-			got := p.GetPathfinder(test.in.grid, test.in.h)
+			got := p.GetPathfinder(test.in.grid, test.in.h, pathfinder.Rules{})
 			if test.want != nil {
 				assert.NotNil(t, got)
+				assert.IsType(t, test.want, got)
 			} else {
 				assert.Nil(t, got)
 			}
@@ -174,6 +246,7 @@ func TestGridProcessor_GetPathfinder(t *testing.T) {
 func TestGridProcessor_Process(t *testing.T) {
 	tests := []struct {
 		name string
+		opts []GridProcessorOption
 		in   *input.TestCase
 		want string
 		err  error
@@ -190,6 +263,59 @@ func TestGridProcessor_Process(t *testing.T) {
 			want: "Test case #1: Optimal solution takes 2 hops.",
 			err:  nil,
 		},
+		{
+			name: "valid path with hops",
+			opts: []GridProcessorOption{WithProcessorHops(true)},
+			in: &input.TestCase{
+				ID:       1,
+				GridRows: 3,
+				GridCols: 3,
+				Start:    input.CellCoordinates{X: 0, Y: 0},
+				End:      input.CellCoordinates{X: 2, Y: 2},
+			},
+			want: "Test case #1: Optimal solution takes 2 hops.\n" +
+				"  start at (0,0)\n" +
+				"  hop 1: land at (1,1) with velocity (1,1) after acceleration (1,1)\n" +
+				"  hop 2: land at (2,2) with velocity (1,1) after acceleration (0,0)\n" +
+				"  finish at (2,2)",
+			err: nil,
+		},
+		{
+			name: "valid path with distance field",
+			opts: []GridProcessorOption{WithProcessorField(true)},
+			in: &input.TestCase{
+				ID:       1,
+				GridRows: 1,
+				GridCols: 5,
+				Start:    input.CellCoordinates{X: 0, Y: 0},
+				End:      input.CellCoordinates{X: 4, Y: 0},
+			},
+			want: "Test case #1: Optimal solution takes 3 hops.\n" +
+				"  0 1 2 2 3\n" +
+				"  unreachable cells: none",
+			err: nil,
+		},
+		{
+			name: "no path with distance field",
+			opts: []GridProcessorOption{WithProcessorField(true), WithProcessorHops(true)},
+			in: &input.TestCase{
+				ID:       1,
+				GridRows: 3,
+				GridCols: 3,
+				Start:    input.CellCoordinates{X: 0, Y: 0},
+				End:      input.CellCoordinates{X: 2, Y: 2},
+				Obstacles: []input.Obstacle{
+					{X1: 1, Y1: 0, X2: 1, Y2: 2},
+					{X1: 0, Y1: 1, X2: 2, Y2: 1},
+				},
+			},
+			want: "Test case #1: No solution.\n" +
+				"  0 # .\n" +
+				"  # # #\n" +
+				"  . # .\n" +
+				"  unreachable cells: (2,0) (0,2) (2,2)",
+			err: nil,
+		},
 		{
 			name: "no path",
 			in: &input.TestCase{
@@ -206,6 +332,366 @@ func TestGridProcessor_Process(t *testing.T) {
 			want: "Test case #1: No solution.",
 			err:  nil,
 		},
+		{
+			name: "valid path with breadth-first search",
+			opts: []GridProcessorOption{WithProcessorAlgorithm(pathfinder.AlgorithmBFS)},
+			in: &input.TestCase{
+				ID:       1,
+				GridRows: 5,
+				GridCols: 5,
+				Start:    input.CellCoordinates{X: 4, Y: 0},
+				End:      input.CellCoordinates{X: 4, Y: 4},
+				Obstacles: []input.Obstacle{
+					{X1: 1, X2: 4, Y1: 2, Y2: 3},
+				},
+			},
+			want: "Test case #1: Optimal solution takes 7 hops.",
+			err:  nil,
+		},
+		{
+			name: "classic rules on a long straight",
+			in: &input.TestCase{
+				ID:       1,
+				GridRows: 1,
+				GridCols: 15,
+				Start:    input.CellCoordinates{X: 0, Y: 0},
+				End:      input.CellCoordinates{X: 14, Y: 0},
+			},
+			want: "Test case #1: Optimal solution takes 6 hops.",
+			err:  nil,
+		},
+		{
+			name: "processor rules on a long straight",
+			opts: []GridProcessorOption{WithProcessorRules(pathfinder.Rules{MaxSpeed: 5})},
+			in: &input.TestCase{
+				ID:       1,
+				GridRows: 1,
+				GridCols: 15,
+				Start:    input.CellCoordinates{X: 0, Y: 0},
+				End:      input.CellCoordinates{X: 14, Y: 0},
+			},
+			want: "Test case #1: Optimal solution takes 5 hops.",
+			err:  nil,
+		},
+		{
+			name: "test case rules override processor rules",
+			opts: []GridProcessorOption{WithProcessorRules(pathfinder.Rules{MaxSpeed: 5})},
+			in: &input.TestCase{
+				ID:       1,
+				GridRows: 1,
+				GridCols: 15,
+				Start:    input.CellCoordinates{X: 0, Y: 0},
+				End:      input.CellCoordinates{X: 14, Y: 0},
+				Rules:    map[string]string{"max-acceleration": "2"},
+			},
+			want: "Test case #1: Optimal solution takes 4 hops.",
+			err:  nil,
+		},
+		{
+			name: "hopping over a wall",
+			in: &input.TestCase{
+				ID:       1,
+				GridRows: 2,
+				GridCols: 7,
+				Start:    input.CellCoordinates{X: 4, Y: 1},
+				End:      input.CellCoordinates{X: 2, Y: 1},
+				Obstacles: []input.Obstacle{
+					{X1: 3, X2: 3, Y1: 0, Y2: 1},
+				},
+			},
+			want: "Test case #1: Optimal solution takes 4 hops.",
+			err:  nil,
+		},
+		{
+			name: "colliding with a wall in flight",
+			in: &input.TestCase{
+				ID:       1,
+				GridRows: 2,
+				GridCols: 7,
+				Start:    input.CellCoordinates{X: 4, Y: 1},
+				End:      input.CellCoordinates{X: 2, Y: 1},
+				Obstacles: []input.Obstacle{
+					{X1: 3, X2: 3, Y1: 0, Y2: 1},
+				},
+				Rules: map[string]string{"flight-collision": "true"},
+			},
+			want: "Test case #1: No solution.",
+			err:  nil,
+		},
+		{
+			name: "crossing a finish line",
+			in: &input.TestCase{
+				ID:         1,
+				GridRows:   5,
+				GridCols:   5,
+				Start:      input.CellCoordinates{X: 3, Y: 4},
+				FinishLine: &input.Segment{From: input.CellCoordinates{X: 1, Y: 3}, To: input.CellCoordinates{X: 3, Y: 2}},
+				Obstacles: []input.Obstacle{
+					{X1: 2, X2: 3, Y1: 1, Y2: 2},
+					{X1: 2, X2: 2, Y1: 3, Y2: 4},
+				},
+			},
+			want: "Test case #1: Optimal solution takes 2 hops.",
+			err:  nil,
+		},
+		{
+			name: "start and finish zones",
+			in: &input.TestCase{
+				ID:          1,
+				GridRows:    1,
+				GridCols:    12,
+				Start:       input.CellCoordinates{X: 0, Y: 0},
+				End:         input.CellCoordinates{X: 4, Y: 0},
+				StartZones:  []input.Area{{X1: 9, X2: 11, Y1: 0, Y2: 0}},
+				FinishZones: []input.Area{{X1: 7, X2: 8, Y1: 0, Y2: 0}},
+				Obstacles: []input.Obstacle{
+					{X1: 9, X2: 9, Y1: 0, Y2: 0},
+				},
+			},
+			want: "Test case #1: Optimal solution takes 2 hops from (11,0) to (8,0).",
+			err:  nil,
+		},
+		{
+			name: "finish line with finish zone",
+			in: &input.TestCase{
+				ID:          1,
+				GridRows:    1,
+				GridCols:    12,
+				Start:       input.CellCoordinates{X: 5, Y: 0},
+				FinishLine:  &input.Segment{From: input.CellCoordinates{X: 11, Y: 0}, To: input.CellCoordinates{X: 11, Y: 0}},
+				FinishZones: []input.Area{{X1: 0, X2: 0, Y1: 0, Y2: 0}},
+			},
+			want: "Test case #1: Optimal solution takes 3 hops from (5,0) to (0,0).",
+			err:  nil,
+		},
+		{
+			name: "timed obstacles",
+			in: &input.TestCase{
+				ID:       1,
+				GridRows: 1,
+				GridCols: 7,
+				Start:    input.CellCoordinates{X: 0, Y: 0},
+				End:      input.CellCoordinates{X: 6, Y: 0},
+				Obstacles: []input.Obstacle{
+					{X1: 3, X2: 3, Y1: 0, Y2: 0, ActiveFor: 1, Period: 2},
+				},
+			},
+			want: "Test case #1: Optimal solution takes 4 hops.",
+			err:  nil,
+		},
+		{
+			name: "terrain",
+			in: &input.TestCase{
+				ID:       1,
+				GridRows: 1,
+				GridCols: 7,
+				Start:    input.CellCoordinates{X: 0, Y: 0},
+				End:      input.CellCoordinates{X: 6, Y: 0},
+				Terrain:  []input.Terrain{{X1: 3, X2: 3, Y1: 0, Y2: 0, Cost: 5}},
+			},
+			want: "Test case #1: Optimal solution takes 4 hops at cost 4.",
+			err:  nil,
+		},
+		{
+			name: "terrain with start and finish zones",
+			in: &input.TestCase{
+				ID:          1,
+				GridRows:    1,
+				GridCols:    5,
+				Start:       input.CellCoordinates{X: 0, Y: 0},
+				End:         input.CellCoordinates{X: 4, Y: 0},
+				StartZones:  []input.Area{{X1: 1, X2: 1, Y1: 0, Y2: 0}},
+				FinishZones: []input.Area{{X1: 3, X2: 3, Y1: 0, Y2: 0}},
+				Terrain:     []input.Terrain{{X1: 3, X2: 3, Y1: 0, Y2: 0, Cost: 2}},
+			},
+			want: "Test case #1: Optimal solution takes 2 hops at cost 2 from (1,0) to (4,0).",
+			err:  nil,
+		},
+		{
+			name: "iterative deepening A* selected by config",
+			opts: []GridProcessorOption{WithProcessorAlgorithm(pathfinder.AlgorithmIDAStar)},
+			in: &input.TestCase{
+				ID:       1,
+				GridRows: 1,
+				GridCols: 7,
+				Start:    input.CellCoordinates{X: 0, Y: 0},
+				End:      input.CellCoordinates{X: 6, Y: 0},
+				Terrain:  []input.Terrain{{X1: 3, X2: 3, Y1: 0, Y2: 0, Cost: 5}},
+			},
+			want: "Test case #1: Optimal solution takes 4 hops at cost 4.",
+			err:  nil,
+		},
+		{
+			name: "iterative deepening A* selected by test case",
+			opts: []GridProcessorOption{WithProcessorAlgorithm(pathfinder.AlgorithmBFS), WithProcessorHops(true)},
+			in: &input.TestCase{
+				ID:        1,
+				GridRows:  3,
+				GridCols:  3,
+				Start:     input.CellCoordinates{X: 0, Y: 0},
+				End:       input.CellCoordinates{X: 2, Y: 2},
+				Algorithm: "idastar",
+			},
+			want: "Test case #1: Optimal solution takes 2 hops.\n" +
+				"  start at (0,0)\n" +
+				"  hop 1: land at (1,1) with velocity (1,1) after acceleration (1,1)\n" +
+				"  hop 2: land at (2,2) with velocity (1,1) after acceleration (0,0)\n" +
+				"  finish at (2,2)",
+			err: nil,
+		},
+		{
+			name: "bidirectional A* selected by test case",
+			in: &input.TestCase{
+				ID:        1,
+				GridRows:  3,
+				GridCols:  10,
+				Start:     input.CellCoordinates{X: 0, Y: 1},
+				End:       input.CellCoordinates{X: 9, Y: 1},
+				Obstacles: []input.Obstacle{{X1: 5, X2: 5, Y1: 0, Y2: 1}},
+				Algorithm: "bidirectional",
+			},
+			want: "Test case #1: Optimal solution takes 4 hops.",
+			err:  nil,
+		},
+		{
+			name: "optimal races count",
+			opts: []GridProcessorOption{WithProcessorCount(true)},
+			in: &input.TestCase{
+				ID:       1,
+				GridRows: 1,
+				GridCols: 5,
+				Start:    input.CellCoordinates{X: 0, Y: 0},
+				End:      input.CellCoordinates{X: 4, Y: 0},
+			},
+			want: "Test case #1: Optimal solution takes 3 hops.\n" +
+				"  optimal races: 2",
+			err: nil,
+		},
+		{
+			name: "optimal races list",
+			opts: []GridProcessorOption{WithProcessorList(1)},
+			in: &input.TestCase{
+				ID:       1,
+				GridRows: 1,
+				GridCols: 5,
+				Start:    input.CellCoordinates{X: 0, Y: 0},
+				End:      input.CellCoordinates{X: 4, Y: 0},
+			},
+			want: "Test case #1: Optimal solution takes 3 hops.\n" +
+				"  optimal races: 2\n" +
+				"  optimal race 1:\n" +
+				"    start at (0,0)\n" +
+				"    hop 1: land at (1,0) with velocity (1,0) after acceleration (1,0)\n" +
+				"    hop 2: land at (2,0) with velocity (1,0) after acceleration (0,0)\n" +
+				"    hop 3: land at (4,0) with velocity (2,0) after acceleration (1,0)\n" +
+				"    finish at (4,0)",
+			err: nil,
+		},
+		{
+			name: "optimal races count with terrain",
+			opts: []GridProcessorOption{WithProcessorCount(true)},
+			in: &input.TestCase{
+				ID:       1,
+				GridRows: 1,
+				GridCols: 7,
+				Start:    input.CellCoordinates{X: 0, Y: 0},
+				End:      input.CellCoordinates{X: 6, Y: 0},
+				Terrain:  []input.Terrain{{X1: 3, X2: 3, Y1: 0, Y2: 0, Cost: 5}},
+			},
+			// the races counted take fewer hops than the cheapest one, landing on the terrain
+			want: "Test case #1: Optimal solution takes 4 hops at cost 4.\n" +
+				"  hop-optimal races: 1",
+			err: nil,
+		},
+		{
+			name: "optimal races count with no solution",
+			opts: []GridProcessorOption{WithProcessorCount(true)},
+			in: &input.TestCase{
+				ID:       1,
+				GridRows: 1,
+				GridCols: 5,
+				Start:    input.CellCoordinates{X: 0, Y: 0},
+				End:      input.CellCoordinates{X: 4, Y: 0},
+				Obstacles: []input.Obstacle{
+					{X1: 1, X2: 1, Y1: 0, Y2: 0},
+				},
+			},
+			want: "Test case #1: No solution.",
+			err:  nil,
+		},
+		{
+			name: "search aborted on expansion limit",
+			opts: []GridProcessorOption{WithProcessorSearchOptions(pathfinder.WithMaxExpansions(3))},
+			in: &input.TestCase{
+				ID:       1,
+				GridRows: 1,
+				GridCols: 12,
+				Start:    input.CellCoordinates{X: 0, Y: 0},
+				End:      input.CellCoordinates{X: 11, Y: 0},
+			},
+			want: "Test case #1: Search aborted (expansion limit exceeded). Best partial race takes 3 hops from (0,0) to (5,0).",
+			err:  nil,
+		},
+		{
+			name: "search aborted on open set limit",
+			opts: []GridProcessorOption{
+				WithProcessorAlgorithm(pathfinder.AlgorithmBFS),
+				WithProcessorSearchOptions(pathfinder.WithMaxOpen(2)),
+			},
+			in: &input.TestCase{
+				ID:       1,
+				GridRows: 1,
+				GridCols: 12,
+				Start:    input.CellCoordinates{X: 0, Y: 0},
+				End:      input.CellCoordinates{X: 11, Y: 0},
+			},
+			want: "Test case #1: Search aborted (open set limit exceeded). Best partial race takes 2 hops from (0,0) to (3,0).",
+			err:  nil,
+		},
+		{
+			name: "invalid test case rules",
+			in: &input.TestCase{
+				ID:       1,
+				GridRows: 3,
+				GridCols: 3,
+				Rules:    map[string]string{"max-speed": "fast"},
+			},
+			want: "",
+			err:  errors.New("invalid test case rules"),
+		},
+		{
+			name: "invalid test case algorithm",
+			in: &input.TestCase{
+				ID:        1,
+				GridRows:  3,
+				GridCols:  3,
+				Algorithm: "dfs",
+			},
+			want: "",
+			err:  errors.New("invalid test case algorithm"),
+		},
+		{
+			name: "invalid test case terrain",
+			in: &input.TestCase{
+				ID:       1,
+				GridRows: 3,
+				GridCols: 3,
+				Terrain:  []input.Terrain{{X1: 0, X2: 2, Y1: 0, Y2: 2, Cost: -2}},
+			},
+			want: "",
+			err:  errors.New("invalid test case terrain"),
+		},
+		{
+			name: "failed to create pathfinder",
+			opts: []GridProcessorOption{WithProcessorAlgorithm("dfs")},
+			in: &input.TestCase{
+				ID:       1,
+				GridRows: 3,
+				GridCols: 3,
+			},
+			want: "",
+			err:  errors.New("failed to create pathfinder"),
+		},
 		{
 			name: "invalid input",
 			in:   nil,
@@ -224,16 +710,173 @@ func TestGridProcessor_Process(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			p := NewGridProcessor()
+			p := NewGridProcessor(test.opts...)
 
-			got, err := p.Process(test.in)
-			assert.Equal(t, test.want, got)
+			got, err := p.Process(context.Background(), test.in)
+			assert.Equal(t, test.want, got.Text)
 			if test.err != nil {
 				assert.Error(t, err)
 				assert.ErrorContains(t, err, test.err.Error())
 			} else {
 				assert.NoError(t, err)
+				assert.Equal(t, test.in.ID, got.ID)
+				assert.NotNil(t, got.Stats)
 			}
 		})
 	}
 }
+
+func TestGridProcessor_Process_Stats(t *testing.T) {
+	in := &input.TestCase{
+		ID:       1,
+		GridRows: 1,
+		GridCols: 3,
+		Start:    input.CellCoordinates{X: 0, Y: 0},
+		End:      input.CellCoordinates{X: 2, Y: 0},
+	}
+
+	p := NewGridProcessor(WithProcessorStats(true))
+
+	got, err := p.Process(context.Background(), in)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, got.ID)
+	assert.Equal(t, 2, got.Stats.Expanded)
+	assert.Equal(t, 3, got.Stats.Generated)
+	assert.Equal(t, 0, got.Stats.Reopened)
+	assert.Equal(t, 1, got.Stats.PeakOpen)
+	assert.Equal(t, "Test case #1: Optimal solution takes 2 hops.\n  stats: "+got.Stats.String(), got.Text)
+	assert.Regexp(t, `stats: expanded=2 generated=3 reopened=0 peak-open=1 time=\S+$`, got.Text)
+
+	// the statistics are reported for aborted searches as well
+	p = NewGridProcessor(WithProcessorStats(true), WithProcessorSearchOptions(pathfinder.WithMaxExpansions(1)))
+
+	got, err = p.Process(context.Background(), in)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, got.Stats.Expanded)
+	assert.Contains(t, got.Text, "Search aborted (expansion limit exceeded).")
+	assert.Contains(t, got.Text, "\n  stats: expanded=1 ")
+}
+
+// TestGridProcessor_Process_Concurrent checks that the test cases processed at once by the workers
+// sharing the processor get the statistics of their own searches.
+func TestGridProcessor_Process_Concurrent(t *testing.T) {
+	// the search options have spare capacity the test cases must not append to
+	opts := make([]pathfinder.SearchOption, 0, 4)
+	opts = append(opts, pathfinder.WithMaxExpansions(1000))
+
+	p := NewGridProcessor(WithProcessorSearchOptions(opts...))
+
+	var wg sync.WaitGroup
+	results := make([]Result, 20)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			// the longer the track, the more states are expanded
+			got, err := p.Process(context.Background(), &input.TestCase{
+				ID:       i,
+				GridRows: 1,
+				GridCols: i + 2,
+				Start:    input.CellCoordinates{X: 0, Y: 0},
+				End:      input.CellCoordinates{X: i + 1, Y: 0},
+			})
+			assert.NoError(t, err)
+			results[i] = got
+		}(i)
+	}
+	wg.Wait()
+
+	for i, got := range results {
+		want, err := p.Process(context.Background(), &input.TestCase{
+			ID:       i,
+			GridRows: 1,
+			GridCols: i + 2,
+			Start:    input.CellCoordinates{X: 0, Y: 0},
+			End:      input.CellCoordinates{X: i + 1, Y: 0},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, want.Stats.Expanded, got.Stats.Expanded, "test case #%d", i)
+	}
+}
+
+func TestGridProcessor_Process_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	p := NewGridProcessor()
+
+	got, err := p.Process(ctx, &input.TestCase{
+		ID:       1,
+		GridRows: 3,
+		GridCols: 3,
+		Start:    input.CellCoordinates{X: 0, Y: 0},
+		End:      input.CellCoordinates{X: 2, Y: 2},
+	})
+	assert.Equal(t, Result{}, got)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestGridProcessor_Process_StopAtFinish(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		rules    pathfinder.Rules
+		want     []string
+	}{
+		{
+			name:     "valid input with any speed at finish",
+			filePath: "../test/resource/valid.txt",
+			rules:    pathfinder.Rules{},
+			want: []string{
+				"Test case #1: Optimal solution takes 7 hops.",
+				"Test case #2: No solution.",
+			},
+		},
+		{
+			name:     "valid input with stop at finish",
+			filePath: "../test/resource/valid.txt",
+			rules:    pathfinder.Rules{StopAtFinish: true},
+			want: []string{
+				"Test case #1: Optimal solution takes 7 hops.",
+				"Test case #2: No solution.",
+			},
+		},
+		{
+			name:     "valid input with rules and any speed at finish",
+			filePath: "../test/resource/valid_rules.txt",
+			rules:    pathfinder.Rules{},
+			want: []string{
+				"Test case #1: Optimal solution takes 5 hops.",
+				"Test case #2: Optimal solution takes 5 hops.",
+			},
+		},
+		{
+			name:     "valid input with rules and stop at finish",
+			filePath: "../test/resource/valid_rules.txt",
+			rules:    pathfinder.Rules{StopAtFinish: true},
+			want: []string{
+				"Test case #1: Optimal solution takes 7 hops.",
+				"Test case #2: Optimal solution takes 6 hops.",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testCases, err := input.ParseTestCases(test.filePath)
+			assert.NoError(t, err)
+
+			p := NewGridProcessor(WithProcessorRules(test.rules))
+
+			var got []string
+			for _, testCase := range testCases {
+				result, err := p.Process(context.Background(), testCase)
+				assert.NoError(t, err)
+
+				got = append(got, result.Text)
+			}
+
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package dispatcher
+
+import "github.com/laonix/hopping-race-tracks/pathfinder"
+
+// Result represents the result of processing a single test case.
+type Result struct {
+	// ID is the ID of the test case.
+	ID int `json:"id"`
+	// Text is the human-readable report on the test case.
+	Text string `json:"result"`
+	// Stats holds the statistics of the search of the test case.
+	Stats *pathfinder.SearchStats `json:"stats,omitempty"`
+}
+
+// String returns the human-readable report on the test case.
+func (r Result) String() string {
+	return r.Text
+}
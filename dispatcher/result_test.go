@@ -0,0 +1,53 @@
+package dispatcher
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/laonix/hopping-race-tracks/pathfinder"
+)
+
+func TestResult_String(t *testing.T) {
+	r := Result{ID: 1, Text: "Test case #1: No solution."}
+	assert.Equal(t, "Test case #1: No solution.", r.String())
+}
+
+func TestResult_JSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Result
+		want string
+	}{
+		{
+			name: "result with stats",
+			in: Result{
+				ID:   1,
+				Text: "Test case #1: Optimal solution takes 2 hops.",
+				Stats: &pathfinder.SearchStats{
+					Expanded:  2,
+					Generated: 3,
+					Reopened:  0,
+					PeakOpen:  1,
+					Duration:  time.Millisecond,
+				},
+			},
+			want: `{"id":1,"result":"Test case #1: Optimal solution takes 2 hops.",` +
+				`"stats":{"expanded":2,"generated":3,"reopened":0,"peak_open":1,"duration":1000000}}`,
+		},
+		{
+			name: "result without stats",
+			in:   Result{ID: 2, Text: "Test case #2: No solution."},
+			want: `{"id":2,"result":"Test case #2: No solution."}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := json.Marshal(test.in)
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, string(got))
+		})
+	}
+}
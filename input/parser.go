@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"unicode"
 
 	"github.com/pkg/errors"
 )
@@ -18,8 +20,27 @@ type TestCase struct {
 
 	Start CellCoordinates
 	End   CellCoordinates
+	// FinishLine is the finish line the hopper has to cross.
+	// If set, it replaces the end coordinates, which are left zero.
+	FinishLine *Segment
+
+	// StartZones are the areas of additional cells the hopper may start the race from.
+	StartZones []Area
+	// FinishZones are the areas of additional cells the hopper may finish the race at.
+	FinishZones []Area
 
 	Obstacles []Obstacle
+
+	// Terrain are the areas changing the cost of landing on their cells.
+	Terrain []Terrain
+
+	// Rules holds the game rules settings overriding the default ones for the test case
+	// (e.g., "max-speed": "5").
+	Rules map[string]string
+
+	// Algorithm is the name of the path finding algorithm solving the test case
+	// instead of the configured one (e.g., "idastar"); it is empty unless set.
+	Algorithm string
 }
 
 // CellCoordinates represents the coordinates of a cell in the grid.
@@ -28,16 +49,77 @@ type CellCoordinates struct {
 	Y int
 }
 
+// Segment represents a straight line between two cells of the grid.
+type Segment struct {
+	From CellCoordinates
+	To   CellCoordinates
+}
+
 // Obstacle represents an area in a grid that is not available for hopping.
+//
+// An obstacle is active all the time unless it has a schedule:
+// it is active for ActiveFor hops since hop ActiveFrom (forever, if ActiveFor is zero),
+// and the window repeats every Period hops if Period is set.
 type Obstacle struct {
 	X1 int
 	X2 int
 	Y1 int
 	Y2 int
+
+	ActiveFrom int
+	ActiveFor  int
+	Period     int
+}
+
+// Terrain represents an area of the grid adding the cost to landing on its cells
+// (negative for the cells making a hop cheaper).
+type Terrain struct {
+	X1   int
+	X2   int
+	Y1   int
+	Y2   int
+	Cost int
+}
+
+// Area represents a rectangular area of the grid: all the cells (x,y) with X1 ≤ x ≤ X2 and Y1 ≤ y ≤ Y2.
+type Area struct {
+	X1 int
+	X2 int
+	Y1 int
+	Y2 int
+}
+
+// DefaultMaxGridSize is the maximal number of rows and columns of a grid accepted by default.
+const DefaultMaxGridSize = 30
+
+// parser holds the settings of the test cases parsing.
+type parser struct {
+	// maxGridSize is the maximal number of rows and columns of a grid;
+	// a non-positive value means the size of a grid is not limited.
+	maxGridSize int
+}
+
+// ParserOption provides a way to configure the test cases parsing.
+type ParserOption func(p *parser)
+
+// WithParserMaxGridSize sets the maximal number of rows and columns of a grid (DefaultMaxGridSize by default).
+// A non-positive size removes the limit.
+func WithParserMaxGridSize(size int) ParserOption {
+	return func(p *parser) {
+		p.maxGridSize = size
+	}
 }
 
 // ParseTestCases reads the test cases from the specified file and returns them as a slice.
-func ParseTestCases(fileName string) ([]*TestCase, error) {
+func ParseTestCases(fileName string, opts ...ParserOption) ([]*TestCase, error) {
+	p := &parser{
+		maxGridSize: DefaultMaxGridSize,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
 	lines, err := getFileLines(fileName)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get file content")
@@ -69,22 +151,29 @@ func ParseTestCases(fileName string) ([]*TestCase, error) {
 		if err != nil {
 			return nil, errors.Wrap(err, fmt.Sprintf("test case %d: failed to parse grid rows and columns", testCase.ID))
 		}
-		if testCase.GridRows < 1 || testCase.GridCols < 1 || testCase.GridRows > 30 || testCase.GridCols > 30 {
+		if testCase.GridRows < 1 || testCase.GridCols < 1 || p.exceedsMaxGridSize(testCase.GridRows, testCase.GridCols) {
 			return nil, errors.New(fmt.Sprintf("test case %d: invalid grid size", testCase.ID))
 		}
 
 		// start and end coordinates
 		i++
-		_, err = fmt.Sscanf(lines[i], "%d %d %d %d", &testCase.Start.X, &testCase.Start.Y, &testCase.End.X, &testCase.End.Y)
+		if len(strings.Fields(lines[i])) == 6 {
+			// the end is a finish line
+			testCase.FinishLine = &Segment{}
+			_, err = fmt.Sscanf(lines[i], "%d %d %d %d %d %d", &testCase.Start.X, &testCase.Start.Y,
+				&testCase.FinishLine.From.X, &testCase.FinishLine.From.Y,
+				&testCase.FinishLine.To.X, &testCase.FinishLine.To.Y)
+		} else {
+			_, err = fmt.Sscanf(lines[i], "%d %d %d %d", &testCase.Start.X, &testCase.Start.Y, &testCase.End.X, &testCase.End.Y)
+		}
 		if err != nil {
 			return nil, errors.Wrap(err, fmt.Sprintf("test case %d: failed to parse start and end coordinates", testCase.ID))
 		}
-		if testCase.Start.X < 0 || testCase.Start.Y < 0 ||
-			testCase.Start.X >= testCase.GridCols || testCase.Start.Y >= testCase.GridRows {
+		if !testCase.contains(testCase.Start) {
 			return nil, errors.New(fmt.Sprintf("test case %d: invalid start coordinates", testCase.ID))
 		}
-		if testCase.End.X < 0 || testCase.End.Y < 0 ||
-			testCase.End.X >= testCase.GridCols || testCase.End.Y >= testCase.GridRows {
+		if !testCase.contains(testCase.End) ||
+			testCase.FinishLine != nil && (!testCase.contains(testCase.FinishLine.From) || !testCase.contains(testCase.FinishLine.To)) {
 			return nil, errors.New(fmt.Sprintf("test case %d: invalid end coordinates", testCase.ID))
 		}
 
@@ -100,8 +189,7 @@ func ParseTestCases(fileName string) ([]*TestCase, error) {
 
 		for j := 0; j < obstaclesCount; j++ {
 			i++
-			var o Obstacle
-			_, err := fmt.Sscanf(lines[i], "%d %d %d %d", &o.X1, &o.X2, &o.Y1, &o.Y2)
+			o, err := parseObstacle(lines[i])
 			if err != nil {
 				return nil, errors.Wrap(err, fmt.Sprintf("test case %d: failed to parse obstacle %d", testCase.ID, j+1))
 			}
@@ -109,18 +197,190 @@ func ParseTestCases(fileName string) ([]*TestCase, error) {
 				o.X2 < 0 || o.X2 >= testCase.GridCols ||
 				o.Y1 < 0 || o.Y1 >= testCase.GridRows ||
 				o.Y2 < 0 || o.Y2 >= testCase.GridRows ||
-				o.X1 > o.X2 || o.Y1 > o.Y2 {
+				o.X1 > o.X2 || o.Y1 > o.Y2 ||
+				!o.validSchedule() {
 				return nil, errors.New(fmt.Sprintf("test case %d: invalid obstacle %d", testCase.ID, j+1))
 			}
 			testCase.Obstacles = append(testCase.Obstacles, o)
 		}
 
+		// optional settings
+		for i+1 < len(lines) && isSettingsLine(lines[i+1]) {
+			i++
+			section := strings.Fields(lines[i])[0]
+
+			switch section {
+			case rulesSection:
+				settings, err := parseSettingsLine(lines[i])
+				if err != nil {
+					return nil, errors.Wrap(err, fmt.Sprintf("test case %d: failed to parse settings", testCase.ID))
+				}
+
+				if testCase.Rules == nil {
+					testCase.Rules = make(map[string]string)
+				}
+				for key, value := range settings {
+					testCase.Rules[key] = value
+				}
+			case searchSection:
+				settings, err := parseSettingsLine(lines[i])
+				if err != nil {
+					return nil, errors.Wrap(err, fmt.Sprintf("test case %d: failed to parse settings", testCase.ID))
+				}
+
+				for key, value := range settings {
+					if key != algorithmSetting {
+						return nil, errors.New(fmt.Sprintf("test case %d: unknown search setting %q", testCase.ID, key))
+					}
+					testCase.Algorithm = value
+				}
+			case startSection, finishSection:
+				var a Area
+				_, err := fmt.Sscanf(lines[i], section+" %d %d %d %d", &a.X1, &a.X2, &a.Y1, &a.Y2)
+				if err != nil {
+					return nil, errors.Wrap(err, fmt.Sprintf("test case %d: failed to parse %s zone", testCase.ID, section))
+				}
+				if !testCase.contains(CellCoordinates{X: a.X1, Y: a.Y1}) ||
+					!testCase.contains(CellCoordinates{X: a.X2, Y: a.Y2}) ||
+					a.X1 > a.X2 || a.Y1 > a.Y2 {
+					return nil, errors.New(fmt.Sprintf("test case %d: invalid %s zone", testCase.ID, section))
+				}
+
+				if section == startSection {
+					testCase.StartZones = append(testCase.StartZones, a)
+				} else {
+					testCase.FinishZones = append(testCase.FinishZones, a)
+				}
+			case terrainSection:
+				var t Terrain
+				_, err := fmt.Sscanf(lines[i], section+" %d %d %d %d %d", &t.X1, &t.X2, &t.Y1, &t.Y2, &t.Cost)
+				if err != nil {
+					return nil, errors.Wrap(err, fmt.Sprintf("test case %d: failed to parse terrain", testCase.ID))
+				}
+				if !testCase.contains(CellCoordinates{X: t.X1, Y: t.Y1}) ||
+					!testCase.contains(CellCoordinates{X: t.X2, Y: t.Y2}) ||
+					t.X1 > t.X2 || t.Y1 > t.Y2 || t.Cost < minTerrainCost {
+					return nil, errors.New(fmt.Sprintf("test case %d: invalid terrain", testCase.ID))
+				}
+
+				testCase.Terrain = append(testCase.Terrain, t)
+			default:
+				return nil, errors.New(fmt.Sprintf("test case %d: unknown settings section %q", testCase.ID, section))
+			}
+		}
+
 		testCases = append(testCases, testCase)
 	}
 
 	return testCases, nil
 }
 
+// parseObstacle parses the obstacle line of the following format:
+//
+//	<x1> <x2> <y1> <y2> [<active-from> [<active-for> [<period>]]]
+//
+// The obstacle without a schedule is active all the time.
+func parseObstacle(line string) (Obstacle, error) {
+	var o Obstacle
+
+	fields := []*int{&o.X1, &o.X2, &o.Y1, &o.Y2, &o.ActiveFrom, &o.ActiveFor, &o.Period}
+
+	values := strings.Fields(line)
+	if len(values) < 4 || len(values) > len(fields) {
+		return o, errors.New(fmt.Sprintf("expected 4 to %d integers, got %d", len(fields), len(values)))
+	}
+
+	for i, v := range values {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return o, errors.Wrap(err, "failed to parse integer")
+		}
+
+		*fields[i] = n
+	}
+
+	return o, nil
+}
+
+// validSchedule reports whether the schedule of the obstacle is valid:
+// the repeated active window must not be longer than its period.
+func (o Obstacle) validSchedule() bool {
+	if o.ActiveFrom < 0 || o.ActiveFor < 0 || o.Period < 0 {
+		return false
+	}
+
+	return o.Period == 0 || o.ActiveFor > 0 && o.ActiveFor <= o.Period
+}
+
+// exceedsMaxGridSize reports whether the grid with the given number of rows and columns is too large.
+func (p *parser) exceedsMaxGridSize(rows, cols int) bool {
+	return p.maxGridSize > 0 && (rows > p.maxGridSize || cols > p.maxGridSize)
+}
+
+// contains reports whether the cell with the given coordinates is inside the grid of the test case.
+func (tc *TestCase) contains(c CellCoordinates) bool {
+	return c.X >= 0 && c.Y >= 0 && c.X < tc.GridCols && c.Y < tc.GridRows
+}
+
+// Names of the settings sections of a test case.
+const (
+	// rulesSection holds the game rules of a test case.
+	rulesSection = "rules"
+	// startSection holds an additional start zone of a test case.
+	startSection = "start"
+	// finishSection holds an additional finish zone of a test case.
+	finishSection = "finish"
+	// terrainSection holds an area of a test case changing the landing cost.
+	terrainSection = "terrain"
+	// searchSection holds the settings of the search of a test case.
+	searchSection = "search"
+)
+
+// algorithmSetting is the key of the search settings selecting the path finding algorithm.
+const algorithmSetting = "algorithm"
+
+// minTerrainCost is the minimal cost a terrain adds to landing on a cell costing 1,
+// so landing never costs less than zero.
+const minTerrainCost = -1
+
+// isSettingsLine reports whether the line holds test case settings.
+//
+// Settings lines start with a section name, so they always begin with a letter,
+// unlike the lines of the test case specification that begin with a number.
+func isSettingsLine(line string) bool {
+	line = strings.TrimSpace(line)
+
+	return line != "" && unicode.IsLetter(rune(line[0]))
+}
+
+// parseSettingsLine parses the key-value pairs of the settings line of the following format:
+//
+//	<section> <key>=<value> <key>=<value> ...
+//
+// A key provided without a value is considered to be set to "true".
+func parseSettingsLine(line string) (map[string]string, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil, errors.New("settings line is empty")
+	}
+
+	settings := make(map[string]string)
+
+	for _, field := range fields[1:] {
+		key, value, found := strings.Cut(field, "=")
+		if key == "" {
+			return nil, errors.New(fmt.Sprintf("invalid setting %q", field))
+		}
+		if !found {
+			value = "true"
+		}
+
+		settings[key] = value
+	}
+
+	return settings, nil
+}
+
 // getFileLines reads the lines from the specified file and returns them as a slice.
 func getFileLines(fileName string) ([]string, error) {
 	f, err := os.Open(fileName)
@@ -11,6 +11,7 @@ func TestParseTestCases(t *testing.T) {
 	tests := []struct {
 		name     string
 		filePath string
+		opts     []ParserOption
 		want     []*TestCase
 		err      error
 	}{
@@ -42,6 +43,118 @@ func TestParseTestCases(t *testing.T) {
 			},
 			err: nil,
 		},
+		{
+			name:     "valid test cases input file with rules",
+			filePath: "../test/resource/valid_rules.txt",
+			want: []*TestCase{
+				{
+					ID:       1,
+					GridRows: 1,
+					GridCols: 15,
+					Start:    CellCoordinates{X: 0, Y: 0},
+					End:      CellCoordinates{X: 14, Y: 0},
+					Rules:    map[string]string{"max-speed": "5"},
+				},
+				{
+					ID:       2,
+					GridRows: 1,
+					GridCols: 15,
+					Start:    CellCoordinates{X: 0, Y: 0},
+					End:      CellCoordinates{X: 14, Y: 0},
+					Rules:    map[string]string{"max-acceleration": "2", "axis-acceleration": "true"},
+				},
+			},
+			err: nil,
+		},
+		{
+			name:     "valid test cases input file with finish line",
+			filePath: "../test/resource/valid_finish_line.txt",
+			want: []*TestCase{
+				{
+					ID:         1,
+					GridRows:   5,
+					GridCols:   5,
+					Start:      CellCoordinates{X: 3, Y: 4},
+					FinishLine: &Segment{From: CellCoordinates{X: 1, Y: 3}, To: CellCoordinates{X: 3, Y: 2}},
+					Obstacles: []Obstacle{
+						{X1: 2, X2: 3, Y1: 1, Y2: 2},
+						{X1: 2, X2: 2, Y1: 3, Y2: 4},
+					},
+				},
+			},
+			err: nil,
+		},
+		{
+			name:     "valid test cases input file with start and finish zones",
+			filePath: "../test/resource/valid_zones.txt",
+			want: []*TestCase{
+				{
+					ID:          1,
+					GridRows:    1,
+					GridCols:    12,
+					Start:       CellCoordinates{X: 0, Y: 0},
+					End:         CellCoordinates{X: 4, Y: 0},
+					StartZones:  []Area{{X1: 9, X2: 11, Y1: 0, Y2: 0}},
+					FinishZones: []Area{{X1: 7, X2: 8, Y1: 0, Y2: 0}},
+					Obstacles: []Obstacle{
+						{X1: 9, X2: 9, Y1: 0, Y2: 0},
+					},
+					Rules: map[string]string{"max-speed": "4"},
+				},
+			},
+			err: nil,
+		},
+		{
+			name:     "valid test cases input file with terrain",
+			filePath: "../test/resource/valid_terrain.txt",
+			want: []*TestCase{
+				{
+					ID:       1,
+					GridRows: 3,
+					GridCols: 5,
+					Start:    CellCoordinates{X: 0, Y: 1},
+					End:      CellCoordinates{X: 4, Y: 1},
+					Terrain: []Terrain{
+						{X1: 1, X2: 3, Y1: 1, Y2: 1, Cost: 10},
+						{X1: 2, X2: 2, Y1: 0, Y2: 0, Cost: -1},
+					},
+				},
+			},
+			err: nil,
+		},
+		{
+			name:     "valid test cases input file with search settings",
+			filePath: "../test/resource/valid_search.txt",
+			want: []*TestCase{
+				{
+					ID:        1,
+					GridRows:  3,
+					GridCols:  5,
+					Start:     CellCoordinates{X: 0, Y: 1},
+					End:       CellCoordinates{X: 4, Y: 1},
+					Algorithm: "idastar",
+				},
+			},
+			err: nil,
+		},
+		{
+			name:     "valid test cases input file with timed obstacles",
+			filePath: "../test/resource/valid_timed.txt",
+			want: []*TestCase{
+				{
+					ID:       1,
+					GridRows: 1,
+					GridCols: 7,
+					Start:    CellCoordinates{X: 0, Y: 0},
+					End:      CellCoordinates{X: 6, Y: 0},
+					Obstacles: []Obstacle{
+						{X1: 3, X2: 3, Y1: 0, Y2: 0, ActiveFrom: 0, ActiveFor: 1, Period: 2},
+						{X1: 5, X2: 5, Y1: 0, Y2: 0, ActiveFrom: 3},
+					},
+				},
+			},
+			err: nil,
+		},
 		{
 			name:     "invalid test cases input file path",
 			filePath: "../test/resource/invalid_path.txt",
@@ -84,6 +197,57 @@ func TestParseTestCases(t *testing.T) {
 			want:     nil,
 			err:      errors.New("failed to parse start and end coordinates"),
 		},
+		{
+			name:     "large grid within the custom size limit",
+			filePath: "../test/resource/valid_large.txt",
+			opts:     []ParserOption{WithParserMaxGridSize(5000)},
+			want: []*TestCase{
+				{
+					ID:       1,
+					GridRows: 4000,
+					GridCols: 5000,
+					Start:    CellCoordinates{X: 0, Y: 0},
+					End:      CellCoordinates{X: 4999, Y: 3999},
+					Obstacles: []Obstacle{
+						{X1: 10, X2: 10, Y1: 0, Y2: 3990},
+						{X1: 100, X2: 4999, Y1: 2000, Y2: 2000},
+					},
+				},
+			},
+			err: nil,
+		},
+		{
+			name:     "large grid with no size limit",
+			filePath: "../test/resource/valid_large.txt",
+			opts:     []ParserOption{WithParserMaxGridSize(0)},
+			want: []*TestCase{
+				{
+					ID:       1,
+					GridRows: 4000,
+					GridCols: 5000,
+					Start:    CellCoordinates{X: 0, Y: 0},
+					End:      CellCoordinates{X: 4999, Y: 3999},
+					Obstacles: []Obstacle{
+						{X1: 10, X2: 10, Y1: 0, Y2: 3990},
+						{X1: 100, X2: 4999, Y1: 2000, Y2: 2000},
+					},
+				},
+			},
+			err: nil,
+		},
+		{
+			name:     "large grid exceeding the default size limit",
+			filePath: "../test/resource/valid_large.txt",
+			want:     nil,
+			err:      errors.New("invalid grid size"),
+		},
+		{
+			name:     "large grid exceeding the custom size limit",
+			filePath: "../test/resource/valid_large.txt",
+			opts:     []ParserOption{WithParserMaxGridSize(4999)},
+			want:     nil,
+			err:      errors.New("invalid grid size"),
+		},
 		{
 			name:     "invalid test case start",
 			filePath: "../test/resource/invalid_start.txt",
@@ -96,6 +260,12 @@ func TestParseTestCases(t *testing.T) {
 			want:     nil,
 			err:      errors.New("invalid end coordinates"),
 		},
+		{
+			name:     "invalid test case finish line",
+			filePath: "../test/resource/invalid_finish_line.txt",
+			want:     nil,
+			err:      errors.New("invalid end coordinates"),
+		},
 		{
 			name:     "invalid test case obstacles count (cannot parse)",
 			filePath: "../test/resource/invalid_obstacles_count_1.txt",
@@ -120,10 +290,58 @@ func TestParseTestCases(t *testing.T) {
 			want:     nil,
 			err:      errors.New("invalid obstacle"),
 		},
+		{
+			name:     "invalid test case obstacle (window longer than period)",
+			filePath: "../test/resource/invalid_obstacle_3.txt",
+			want:     nil,
+			err:      errors.New("invalid obstacle 1"),
+		},
+		{
+			name:     "invalid test case settings (unknown section)",
+			filePath: "../test/resource/invalid_settings_1.txt",
+			want:     nil,
+			err:      errors.New(`unknown settings section "speed"`),
+		},
+		{
+			name:     "invalid test case settings (unknown search setting)",
+			filePath: "../test/resource/invalid_search.txt",
+			want:     nil,
+			err:      errors.New(`unknown search setting "heuristic"`),
+		},
+		{
+			name:     "invalid test case zone (cannot parse)",
+			filePath: "../test/resource/invalid_zone_1.txt",
+			want:     nil,
+			err:      errors.New("failed to parse start zone"),
+		},
+		{
+			name:     "invalid test case zone (invalid coordinates)",
+			filePath: "../test/resource/invalid_zone_2.txt",
+			want:     nil,
+			err:      errors.New("invalid finish zone"),
+		},
+		{
+			name:     "invalid test case terrain (cannot parse)",
+			filePath: "../test/resource/invalid_terrain_1.txt",
+			want:     nil,
+			err:      errors.New("failed to parse terrain"),
+		},
+		{
+			name:     "invalid test case terrain (invalid cost)",
+			filePath: "../test/resource/invalid_terrain_2.txt",
+			want:     nil,
+			err:      errors.New("invalid terrain"),
+		},
+		{
+			name:     "invalid test case settings (cannot parse)",
+			filePath: "../test/resource/invalid_settings_2.txt",
+			want:     nil,
+			err:      errors.New("failed to parse settings"),
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			got, err := ParseTestCases(test.filePath)
+			got, err := ParseTestCases(test.filePath, test.opts...)
 
 			if test.err != nil {
 				assert.Error(t, err)
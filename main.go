@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	golog "log"
@@ -15,11 +16,18 @@ import (
 	"github.com/laonix/hopping-race-tracks/dispatcher"
 	"github.com/laonix/hopping-race-tracks/input"
 	"github.com/laonix/hopping-race-tracks/logger"
+	"github.com/laonix/hopping-race-tracks/pathfinder"
 )
 
 var (
 	file   = flag.String("file", "default.txt", "input file path")
 	config = flag.String("config", "default.yaml", "environment configuration file path")
+	hops   = flag.Bool("hops", false, "print the hops of the optimal solutions")
+	field  = flag.Bool("field", false, "print the minimal number of hops to land on each cell and the unreachable cells")
+	count  = flag.Bool("count", false, "print the number of the optimal solutions")
+	list   = flag.Int("list", 0, "print up to the given number of the optimal solutions together with their number")
+	stats  = flag.Bool("stats", false, "print the statistics of the search of each test case")
+	asJSON = flag.Bool("json", false, "print the results as JSON lines, including the statistics of the searches")
 )
 
 func main() {
@@ -29,7 +37,17 @@ func main() {
 
 	log := logger.Get()
 
-	testCases, err := input.ParseTestCases(*file)
+	algorithm, err := pathfinder.ParseAlgorithm(viper.GetString("pathfinder.algorithm"))
+	if err != nil {
+		log.Fatal(err, "failed to configure pathfinder")
+	}
+
+	rules, err := pathfinder.Rules{}.Apply(viper.GetStringMapString("rules"))
+	if err != nil {
+		log.Fatal(err, "failed to configure game rules")
+	}
+
+	testCases, err := input.ParseTestCases(*file, input.WithParserMaxGridSize(viper.GetInt("input.grid.max-size")))
 	if err != nil {
 		log.Fatal(err, "failed to parse test cases", "file", *file)
 	}
@@ -42,6 +60,20 @@ func main() {
 		dispatcher.WithDispatcherPipeSize(viper.GetInt("dispatcher.pipe.size")),
 		dispatcher.WithDispatcherPoolSize(viper.GetInt("dispatcher.pool.size")),
 		dispatcher.WithDispatcherLogger(log),
+		dispatcher.WithDispatcherProcessor(dispatcher.NewGridProcessor(
+			dispatcher.WithProcessorAlgorithm(algorithm),
+			dispatcher.WithProcessorRules(rules),
+			dispatcher.WithProcessorHops(*hops),
+			dispatcher.WithProcessorField(*field),
+			dispatcher.WithProcessorCount(*count),
+			dispatcher.WithProcessorList(*list),
+			dispatcher.WithProcessorStats(*stats),
+			dispatcher.WithProcessorSearchOptions(
+				pathfinder.WithMaxExpansions(viper.GetInt("pathfinder.limits.max-expansions")),
+				pathfinder.WithMaxOpen(viper.GetInt("pathfinder.limits.max-open")),
+				pathfinder.WithTimeout(viper.GetDuration("pathfinder.limits.timeout")),
+			),
+		)),
 	)
 
 	log.Debug("start processing test cases", "count", len(testCases))
@@ -55,21 +87,50 @@ func main() {
 
 	go func() {
 		for result := range d.Results() {
-			fmt.Println(result)
-			log.Info("test case processed", "result", result)
+			printResult(result)
+			log.Info("test case processed", "id", result.ID, "result", result.Text, "stats", result.Stats)
 
 			wg.Done()
 		}
 	}()
 
-	wg.Wait()
+	// stop waiting for the results once interrupted:
+	// the searches in progress are aborted and their test cases are left unprocessed
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
 
-	log.Debug("all test cases processed")
+	select {
+	case <-done:
+		log.Debug("all test cases processed")
+	case <-ctx.Done():
+		log.Warn("test cases processing interrupted")
+	}
 
 	d.Stop(ctx)
 }
 
+// printResult prints the result of a test case either as text or as a JSON line.
+func printResult(result dispatcher.Result) {
+	if !*asJSON {
+		fmt.Println(result)
+		return
+	}
+
+	line, err := json.Marshal(result)
+	if err != nil {
+		logger.Get().Error(err, "failed to marshal result", "id", result.ID)
+		return
+	}
+
+	fmt.Println(string(line))
+}
+
 func loadConfig() {
+	viper.SetDefault("input.grid.max-size", input.DefaultMaxGridSize)
+
 	viper.SetConfigFile(*config)
 	viper.SetConfigType("yaml")
 
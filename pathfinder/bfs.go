@@ -0,0 +1,111 @@
+package pathfinder
+
+import "context"
+
+// BreadthFirstPathfinder finds the shortest path between two cells in a given grid
+// using the breadth-first search.
+//
+// As every hop costs exactly one, the breadth-first search over the states of the hopper
+// is guaranteed to find the minimal number of hops without relying on any heuristic.
+// It is slower than GridPathfinder, but it may serve as a reference for checking
+// the results of the A* algorithm and its heuristics.
+//
+// If the grid has terrain, the hops cost differently, and the search turns into the uniform-cost search
+// (i.e., A* without a heuristic), which is still independent of any heuristic.
+type BreadthFirstPathfinder struct {
+	Grid  *Grid
+	Rules Rules
+}
+
+// NewBreadthFirstPathfinder returns a new breadth-first pathfinder with the given grid and game rules.
+func NewBreadthFirstPathfinder(grid *Grid, rules Rules) Pathfinder {
+	if grid == nil {
+		return nil
+	}
+
+	return &BreadthFirstPathfinder{
+		Grid:  grid,
+		Rules: rules,
+	}
+}
+
+// FindPath returns the shortest path from any of the start cells to the finish.
+//
+// The states of the hopper are explored in the order of the number of hops
+// needed to reach them, so the first state found to finish the race ends the optimal race.
+//
+// The search does not rely on any heuristic, so the partial race of an aborted search
+// leads to the discovered state with the lowest VelocityDistance estimate.
+func (pf *BreadthFirstPathfinder) FindPath(ctx context.Context, starts []*Cell, finish Goal, opts ...SearchOption) (*Solution, error) {
+	if pf.Grid.Weighted() {
+		// the states are explored in the order of the cost of the races to them instead
+		uniform := &GridPathfinder{Grid: pf.Grid, Heuristic: noDistance, Rules: pf.Rules}
+		return uniform.FindPath(ctx, starts, finish, opts...)
+	}
+
+	initials, err := getEndpoints(pf.Grid, starts, finish)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := newSearchSettings(opts)
+
+	var stats SearchStats
+	defer settings.report(&stats)
+
+	// visited holds the search bookkeeping of every state discovered so far
+	visited := make(map[State]*node, len(initials))
+
+	queue := make([]*node, 0, len(initials))
+	for _, initial := range initials {
+		if _, ok := visited[initial.State()]; ok {
+			continue
+		}
+
+		n := newNode(initial, nil)
+		stats.Generated++
+		if at, ok := pf.Rules.FinishesAt(initial, finish); ok {
+			return newSolution(pf.Grid, n.path(), at), nil
+		}
+
+		visited[n.state()] = n
+		queue = append(queue, n)
+		stats.open(len(queue))
+	}
+
+	for len(queue) > 0 {
+		if err := settings.check(ctx, stats.Expanded, len(queue)); err != nil {
+			return nil, abort(err, pf.Grid, visited, VelocityDistance, finish, pf.Rules)
+		}
+
+		current := queue[0]
+		queue = queue[1:]
+		stats.Expanded++
+
+		for _, next := range pf.Grid.GetNeighbors(current.cell, pf.Rules) {
+			if _, ok := visited[next.State()]; ok {
+				continue
+			}
+
+			neighbor := newNode(next, current)
+			visited[neighbor.state()] = neighbor
+			stats.Generated++
+
+			// the first finishing state found has the minimal number of hops
+			if at, ok := pf.Rules.FinishesAt(next, finish); ok {
+				return newSolution(pf.Grid, neighbor.path(), at), nil
+			}
+
+			queue = append(queue, neighbor)
+			stats.open(len(queue))
+		}
+	}
+
+	// No path found
+	return nil, nil
+}
+
+// noDistance is the heuristic estimating nothing, which turns A* into the uniform-cost search.
+func noDistance(_, _ *Cell, _ Rules) int {
+	return 0
+}
@@ -0,0 +1,180 @@
+package pathfinder
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBreadthFirstPathfinder(t *testing.T) {
+	grid := NewGrid(3, 3)
+
+	got := NewBreadthFirstPathfinder(grid, Rules{})
+	assert.Equal(t, &BreadthFirstPathfinder{Grid: grid}, got)
+
+	assert.Nil(t, NewBreadthFirstPathfinder(nil, Rules{}))
+}
+
+func TestBreadthFirstPathfinder_FindPath(t *testing.T) {
+	tests := []struct {
+		name   string
+		grid   *Grid
+		start  *Cell
+		finish *Cell
+		want   *Solution
+		err    error
+	}{
+		{
+			name:   "valid path found",
+			grid:   NewGrid(3, 3),
+			start:  &Cell{X: 0, Y: 0},
+			finish: &Cell{X: 2, Y: 2},
+			want: &Solution{
+				Start: State{X: 0, Y: 0},
+				Hops: []Hop{
+					{X: 1, Y: 1, Speed: Velocity{X: 1, Y: 1}, Acceleration: Acceleration{X: 1, Y: 1}},
+					{X: 2, Y: 2, Speed: Velocity{X: 1, Y: 1}, Acceleration: Acceleration{X: 0, Y: 0}},
+				},
+				Finish: Position{X: 2, Y: 2},
+				Cost:   2,
+			},
+			err: nil,
+		},
+		{
+			name:   "start is the finish",
+			grid:   NewGrid(3, 3),
+			start:  &Cell{X: 1, Y: 1},
+			finish: &Cell{X: 1, Y: 1},
+			want: &Solution{
+				Start:  State{X: 1, Y: 1},
+				Hops:   []Hop{},
+				Finish: Position{X: 1, Y: 1},
+			},
+			err: nil,
+		},
+		{
+			name: "no path found",
+			grid: NewGrid(3, 3, []Obstacle{
+				{X1: 1, Y1: 0, X2: 1, Y2: 2},
+				{X1: 0, Y1: 1, X2: 2, Y2: 1},
+			}...),
+			start:  &Cell{X: 0, Y: 0},
+			finish: &Cell{X: 2, Y: 2},
+			want:   nil,
+			err:    nil,
+		},
+		{
+			name:   "nil input cell",
+			grid:   NewGrid(3, 3),
+			start:  &Cell{X: 0, Y: 0},
+			finish: nil,
+			want:   nil,
+			err:    errors.New("start and finish cells must be provided"),
+		},
+		{
+			name: "finish cell not available",
+			grid: NewGrid(3, 3, []Obstacle{
+				{X1: 2, Y1: 2, X2: 2, Y2: 2},
+			}...),
+			start:  &Cell{X: 0, Y: 0},
+			finish: &Cell{X: 2, Y: 2},
+			want:   nil,
+			err:    errors.New("finish cell is not available"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := NewBreadthFirstPathfinder(test.grid, Rules{}).FindPath(context.Background(), []*Cell{test.start}, NewLandingGoal(test.finish))
+
+			if test.err != nil {
+				assert.Error(t, err)
+				assert.ErrorContains(t, err, test.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+// TestBreadthFirstPathfinder_FindPath_Reference checks that the A* algorithm
+// with admissible heuristics finds races of the same length as the breadth-first search
+// on random tracks with one or more start and finish cells.
+func TestBreadthFirstPathfinder_FindPath_Reference(t *testing.T) {
+	heuristics := map[string]Heuristic{
+		"no heuristic":      func(a, b *Cell, _ Rules) int { return 0 },
+		"velocity distance": VelocityDistance,
+	}
+
+	rules := map[string]Rules{
+		"classic rules":     {},
+		"fast hoppers":      {MaxSpeed: 5, MaxAcceleration: 2},
+		"axis acceleration": {AxisAcceleration: true},
+		"stop at finish":    {StopAtFinish: true},
+		"flight collision":  {FlightCollision: true},
+	}
+
+	for hName, h := range heuristics {
+		for rName, r := range rules {
+			t.Run(hName+", "+rName, func(t *testing.T) {
+				rnd := rand.New(rand.NewSource(1))
+
+				for i := 0; i < 200; i++ {
+					grid, starts, finish := randomTrack(rnd, 8, 8)
+					goal := NewLandingGoal(finish...)
+
+					want, err := NewBreadthFirstPathfinder(grid, r).FindPath(context.Background(), starts, goal)
+					assert.NoError(t, err)
+
+					got, err := NewGridPathfinder(grid, h, r).FindPath(context.Background(), starts, goal)
+					assert.NoError(t, err)
+
+					assert.Equal(t, want.Len(), got.Len(), "starts %v, finish %v", starts, finish)
+					assert.Equal(t, want == nil, got == nil, "starts %v, finish %v", starts, finish)
+					if got != nil {
+						assert.Contains(t, starts, &Cell{X: got.Start.X, Y: got.Start.Y})
+						assert.True(t, goal.Contains(got.Finish.X, got.Finish.Y))
+					}
+				}
+			})
+		}
+	}
+}
+
+// randomTrack returns a random grid not larger than the given size
+// with a few obstacles, and up to three available start and finish cells on it.
+func randomTrack(r *rand.Rand, maxRows, maxCols int) (*Grid, []*Cell, []*Cell) {
+	for {
+		rows, cols := 1+r.Intn(maxRows), 1+r.Intn(maxCols)
+
+		var obstacles []Obstacle
+		for n := r.Intn(4); n > 0; n-- {
+			x, y := r.Intn(cols), r.Intn(rows)
+			obstacles = append(obstacles, Obstacle{X1: x, X2: x + r.Intn(cols-x), Y1: y, Y2: y + r.Intn(rows-y)})
+		}
+
+		grid := NewGrid(rows, cols, obstacles...)
+		starts := randomCells(r, grid, 1+r.Intn(3))
+		finish := randomCells(r, grid, 1+r.Intn(3))
+
+		if len(starts) > 0 && len(finish) > 0 {
+			return grid, starts, finish
+		}
+	}
+}
+
+// randomCells returns up to n random available cells of the grid.
+func randomCells(r *rand.Rand, grid *Grid, n int) []*Cell {
+	var cells []*Cell
+	for ; n > 0; n-- {
+		if c := grid.GetCell(r.Intn(grid.Cols), r.Intn(grid.Rows)); c.Available {
+			cells = append(cells, &Cell{X: c.X, Y: c.Y})
+		}
+	}
+
+	return cells
+}
@@ -0,0 +1,447 @@
+package pathfinder
+
+import (
+	"container/heap"
+	"context"
+)
+
+// BidirectionalPathfinder finds the shortest path between two cells in a given grid
+// using the bidirectional A* algorithm.
+//
+// It searches forward from the start states and backward from the finishing states at once,
+// and the race is found where both searches meet over the states of the hopper
+// (i.e., a cell together with the velocity the hopper has reached it with).
+// The backward search follows the hops reversed: the hopper lands on cell (x, y) with velocity v
+// by the hop from cell (x - v.X, y - v.Y), which it has left with any velocity the allowed acceleration turns into v.
+//
+// Both searches together explore fewer states than a single one where the number of states within reach
+// of an end grows faster than the cost of the races to them, e.g., when the heuristic leads the forward search astray.
+// On long open tracks, VelocityDistance leads GridPathfinder almost straight to the finish,
+// so the bidirectional search explores more states there.
+//
+// The times of the hops cannot be followed backward, so the races over a grid with timed obstacles
+// are found by GridPathfinder instead.
+//
+// The pathfinder keeps no state between searches, so it can be used
+// for any number of FindPath calls, including concurrent ones.
+type BidirectionalPathfinder struct {
+	Grid      *Grid
+	Heuristic Heuristic
+	Rules     Rules
+}
+
+// NewBidirectionalPathfinder returns a new bidirectional pathfinder with the given grid, heuristic function and game rules.
+func NewBidirectionalPathfinder(grid *Grid, h Heuristic, rules Rules) Pathfinder {
+	if grid == nil || h == nil {
+		return nil
+	}
+
+	return &BidirectionalPathfinder{
+		Grid:      grid,
+		Heuristic: h,
+		Rules:     rules,
+	}
+}
+
+// FindPath returns the shortest path from any of the start cells to the finish.
+//
+// The velocity the finish is reached with is not known beforehand, so the backward search starts
+// from every state finishing the race: every cell the hopper may land on finishing the race
+// together with every velocity of such a hop (the velocities the hopper can stop with, if it has to stop at the finish).
+//
+// The forward states are estimated by the heuristic as GridPathfinder does, and the backward ones
+// by the heuristic estimate of the race reversed: the race from the state with the velocity reversed to the start cells,
+// which VelocityDistance never overestimates either. The direction with fewer open states is expanded in turn,
+// and the search is over once no race not met yet can be cheaper than the cheapest race met.
+//
+// The partial race of an aborted search leads to the state discovered by the forward search
+// with the lowest heuristic estimate.
+func (pf *BidirectionalPathfinder) FindPath(ctx context.Context, starts []*Cell, finish Goal, opts ...SearchOption) (*Solution, error) {
+	if pf.Grid.Timed() {
+		forward := &GridPathfinder{Grid: pf.Grid, Heuristic: pf.Heuristic, Rules: pf.Rules}
+		return forward.FindPath(ctx, starts, finish, opts...)
+	}
+
+	initials, err := getEndpoints(pf.Grid, starts, finish)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := newSearchSettings(opts)
+
+	var stats SearchStats
+	defer settings.report(&stats)
+
+	s := &meetingSearch{
+		pf:       pf,
+		finish:   finish,
+		starts:   make(map[Position]bool, len(initials)),
+		forward:  newFrontier(len(initials)),
+		backward: newFrontier(0),
+		stats:    &stats,
+		cost:     infiniteCost,
+	}
+
+	for _, initial := range initials {
+		if at, ok := pf.Rules.FinishesAt(initial, finish); ok {
+			stats.Generated++
+			return newSolution(pf.Grid, []*Cell{initial}, at), nil
+		}
+
+		if s.starts[Position{X: initial.X, Y: initial.Y}] {
+			continue
+		}
+		s.starts[Position{X: initial.X, Y: initial.Y}] = true
+		s.startCells = append(s.startCells, initial)
+	}
+
+	for _, initial := range s.startCells {
+		s.push(s.forward, newNode(initial, nil), 0, pf.estimate(initial, finish))
+	}
+
+	for _, c := range finishingStates(pf.Grid, finish, pf.Rules) {
+		// the hop is made from a cell of the grid
+		if !pf.Grid.contains(c.X-c.Speed.X, c.Y-c.Speed.Y) {
+			continue
+		}
+		s.push(s.backward, newNode(c, nil), 0, s.estimateBack(c))
+	}
+
+	for s.forward.open.Len() > 0 && s.backward.open.Len() > 0 {
+		if err := settings.check(ctx, stats.Expanded, s.forward.open.Len()+s.backward.open.Len()); err != nil {
+			return nil, abort(err, pf.Grid, s.forward.nodes, pf.Heuristic, finish, pf.Rules)
+		}
+
+		// the race met is the cheapest one once no race not met yet can be cheaper
+		if s.cost <= s.lowerBound() {
+			break
+		}
+
+		// the direction with fewer open states is expanded
+		if s.backward.open.Len() < s.forward.open.Len() {
+			s.expandBackward()
+		} else {
+			s.expandForward()
+		}
+	}
+
+	if s.meet == nil {
+		// No path found
+		return nil, nil
+	}
+
+	return s.solution(), nil
+}
+
+// estimate returns the heuristic estimate of the cost of the hops needed
+// to finish the race from the given cell.
+func (pf *BidirectionalPathfinder) estimate(cell *Cell, finish Goal) int {
+	return estimate(pf.Heuristic, cell, finish, pf.Rules) * pf.Grid.MinLandingCost()
+}
+
+// finishingStates returns the states of the hopper finishing the race with a hop:
+// the hopper lands on an available cell with any velocity of a hop finishing the race.
+//
+// A finishing hop passes through a finish cell, so the cells within the reach of a hop
+// from the finish cells are checked only. The states are returned whether the hop is made
+// from a cell of the grid or not.
+func finishingStates(grid *Grid, finish Goal, rules Rules) []*Cell {
+	maxSpeed := rules.GetMaxSpeed()
+
+	var states []*Cell
+	checked := make(map[Position]bool)
+
+	for _, f := range finish.Cells() {
+		for y := f.Y - maxSpeed; y <= f.Y+maxSpeed; y++ {
+			for x := f.X - maxSpeed; x <= f.X+maxSpeed; x++ {
+				if checked[Position{X: x, Y: y}] || !grid.IsAvailable(x, y) {
+					continue
+				}
+				checked[Position{X: x, Y: y}] = true
+
+				for vy := -maxSpeed; vy <= maxSpeed; vy++ {
+					for vx := -maxSpeed; vx <= maxSpeed; vx++ {
+						// the hopper does not hop in place
+						if vx == 0 && vy == 0 {
+							continue
+						}
+
+						c := &Cell{X: x, Y: y, Available: true, Speed: Velocity{X: vx, Y: vy}}
+						if rules.Finishes(c, finish) {
+							states = append(states, c)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return states
+}
+
+// frontier holds the search bookkeeping of a single direction of the bidirectional search.
+type frontier struct {
+	// nodes holds the search bookkeeping of every state discovered in the direction so far
+	nodes map[State]*node
+	open  *priorityQueue
+
+	// costs counts the open states by the cost of the races to them, and minCost is the lowest cost counted.
+	// The races to the states discovered are never cheaper than the race to the state expanded,
+	// so minCost never decreases.
+	costs   map[int]int
+	minCost int
+}
+
+// newFrontier returns a new frontier with room for the given number of states.
+func newFrontier(size int) *frontier {
+	f := &frontier{
+		nodes: make(map[State]*node, size),
+		open:  &priorityQueue{},
+		costs: make(map[int]int),
+	}
+	heap.Init(f.open)
+
+	return f
+}
+
+// push marks the node as open and pushes it to the open states.
+func (f *frontier) push(n *node) {
+	n.open = true
+	n.closed = false
+
+	f.nodes[n.state()] = n
+	heap.Push(f.open, n)
+	f.count(n.gCost, 1)
+}
+
+// top returns the open node with the lowest fCost.
+func (f *frontier) top() *node {
+	return (*f.open)[0].node
+}
+
+// pop removes the open node with the lowest fCost and marks it as closed.
+func (f *frontier) pop() *node {
+	n := heap.Pop(f.open).(*node)
+	n.open = false
+	n.closed = true
+	f.count(n.gCost, -1)
+
+	return n
+}
+
+// decrease moves the open node reached by a cheaper race up the queue in place.
+func (f *frontier) decrease(n *node, gCost int) {
+	f.count(n.gCost, -1)
+	n.gCost = gCost
+	n.fCost = n.gCost + n.hCost
+	f.count(n.gCost, 1)
+
+	heap.Fix(f.open, f.open.GetIndex(n))
+}
+
+// count adds the delta to the number of the open states reached at the given cost.
+func (f *frontier) count(gCost, delta int) {
+	if f.costs[gCost] += delta; f.costs[gCost] == 0 {
+		delete(f.costs, gCost)
+	}
+	f.minCost = min(f.minCost, gCost)
+}
+
+// lowestCost returns the lowest cost of the races to the open states; there must be any.
+func (f *frontier) lowestCost() int {
+	for f.costs[f.minCost] == 0 {
+		f.minCost++
+	}
+
+	return f.minCost
+}
+
+// meetingSearch holds the state of a single bidirectional search.
+type meetingSearch struct {
+	pf     *BidirectionalPathfinder
+	finish Goal
+
+	// starts holds the positions of the start cells for the lookup, and startCells holds the start cells.
+	starts     map[Position]bool
+	startCells []*Cell
+
+	forward, backward *frontier
+	stats             *SearchStats
+
+	// meet is the state the cheapest race met so far passes through, and cost is the cost of the race.
+	meet *State
+	cost int
+}
+
+// lowerBound returns the cost no race not met yet is cheaper than.
+//
+// Such a race passes through an open state of each direction, reached by the cheapest race to it:
+// its cost is estimated by both of them, and it is not lower than the costs of the races to them together
+// with the cost of at least a single hop between them.
+func (s *meetingSearch) lowerBound() int {
+	return max(
+		s.forward.top().fCost,
+		s.backward.top().fCost,
+		s.forward.lowestCost()+s.backward.lowestCost()+s.pf.Grid.MinLandingCost(),
+	)
+}
+
+// push evaluates the node of a state not discovered yet with the given costs
+// and pushes it to the open states of the given direction.
+func (s *meetingSearch) push(f *frontier, n *node, gCost, hCost int) {
+	n.gCost = gCost
+	n.hCost = hCost
+	n.fCost = n.gCost + n.hCost
+
+	f.push(n)
+	s.stats.Generated++
+	s.stats.open(s.forward.open.Len() + s.backward.open.Len())
+}
+
+// expandForward expands the forward open state with the lowest fCost to the states the hopper can hop to.
+func (s *meetingSearch) expandForward() {
+	current := s.forward.pop()
+
+	// the race is over at the finish, so it is not followed any further
+	if s.pf.Rules.Finishes(current.cell, s.finish) {
+		return
+	}
+
+	s.stats.Expanded++
+
+	for _, next := range s.pf.Grid.GetNeighbors(current.cell, s.pf.Rules) {
+		gCost := current.gCost + s.pf.Grid.LandingCost(next.X, next.Y)
+		s.relax(s.forward, s.backward, current, next, gCost, func(c *Cell) int {
+			return s.pf.estimate(c, s.finish)
+		})
+	}
+}
+
+// expandBackward expands the backward open state with the lowest fCost to the states the hopper can hop from.
+func (s *meetingSearch) expandBackward() {
+	current := s.backward.pop()
+	s.stats.Expanded++
+
+	// the hop to the current state costs the same whichever state it is made from
+	gCost := current.gCost + s.pf.Grid.LandingCost(current.cell.X, current.cell.Y)
+
+	for _, prev := range s.predecessors(current.cell) {
+		s.relax(s.backward, s.forward, current, prev, gCost, s.estimateBack)
+	}
+}
+
+// relax updates the bookkeeping of the state the current node is expanded to in the given direction
+// if the new race to it is cheaper, and checks whether the race meets the other direction there.
+func (s *meetingSearch) relax(f, other *frontier, current *node, next *Cell, gCost int, estimate func(*Cell) int) {
+	neighbor, ok := f.nodes[next.State()]
+	if !ok {
+		neighbor = newNode(next, current)
+		s.push(f, neighbor, gCost, estimate(next))
+	} else if gCost < neighbor.gCost {
+		// an open state is moved up the queue in place, while a closed state is reopened
+		neighbor.parent = current
+
+		if neighbor.open {
+			f.decrease(neighbor, gCost)
+		} else {
+			neighbor.gCost = gCost
+			neighbor.fCost = neighbor.gCost + neighbor.hCost
+			f.push(neighbor)
+			s.stats.Reopened++
+			s.stats.open(s.forward.open.Len() + s.backward.open.Len())
+		}
+	} else {
+		return
+	}
+
+	if m, ok := other.nodes[next.State()]; ok && gCost+m.gCost < s.cost {
+		state := next.State()
+		s.meet, s.cost = &state, gCost+m.gCost
+	}
+}
+
+// predecessors returns the states the hopper can hop to the state of the given cell from.
+//
+// The hopper landing on cell (x, y) with velocity v has hopped from cell (x - v.X, y - v.Y)
+// with any velocity the allowed acceleration turns into v. The hopper rests on the start cells only,
+// and the races finished before are not followed.
+func (s *meetingSearch) predecessors(cell *Cell) []*Cell {
+	// the hopper resting on a start cell has not hopped yet
+	if cell.Speed.X == 0 && cell.Speed.Y == 0 {
+		return nil
+	}
+
+	x, y := cell.X-cell.Speed.X, cell.Y-cell.Speed.Y
+	if !s.pf.Grid.contains(x, y) {
+		return nil
+	}
+
+	// the flight is walked in the direction of the hop, as the forward search does
+	if s.pf.Rules.FlightCollision && !clearFlight(x, y, cell.X, cell.Y, s.pf.Grid.IsAvailable) {
+		return nil
+	}
+
+	available := s.pf.Grid.IsAvailable(x, y)
+	maxSpeed := s.pf.Rules.GetMaxSpeed()
+
+	var prevs []*Cell
+	for _, a := range s.pf.Rules.Accelerations() {
+		speed := Velocity{X: cell.Speed.X - a.X, Y: cell.Speed.Y - a.Y}
+
+		// skip the speed out of the allowed range
+		if speed.X < -maxSpeed || speed.X > maxSpeed || speed.Y < -maxSpeed || speed.Y > maxSpeed {
+			continue
+		}
+
+		if speed.X == 0 && speed.Y == 0 {
+			if s.starts[Position{X: x, Y: y}] {
+				prevs = append(prevs, &Cell{X: x, Y: y, Available: available})
+			}
+			continue
+		}
+
+		if !available {
+			continue
+		}
+
+		prev := &Cell{X: x, Y: y, Available: true, Speed: speed}
+		if s.pf.Rules.Finishes(prev, s.finish) {
+			continue
+		}
+
+		prevs = append(prevs, prev)
+	}
+
+	return prevs
+}
+
+// estimateBack returns the heuristic estimate of the cost of the hops needed
+// to reach the state of the given cell from any of the start cells.
+//
+// The hops of a race reversed make a race as well, which starts from the state with the velocity reversed,
+// so the estimate is the minimal heuristic estimate of that race over all the start cells.
+func (s *meetingSearch) estimateBack(cell *Cell) int {
+	reversed := &Cell{X: cell.X, Y: cell.Y, Speed: Velocity{X: -cell.Speed.X, Y: -cell.Speed.Y}}
+
+	best := -1
+	for _, start := range s.startCells {
+		if e := s.pf.Heuristic(reversed, start, s.pf.Rules); best < 0 || e < best {
+			best = e
+		}
+	}
+
+	return max(best, 0) * s.pf.Grid.MinLandingCost()
+}
+
+// solution returns the cheapest race met: the race from the start to the meeting state found by the forward search
+// followed by the race from there to the finish found by the backward search.
+func (s *meetingSearch) solution() *Solution {
+	path := s.forward.nodes[*s.meet].path()
+	for n := s.backward.nodes[*s.meet].parent; n != nil; n = n.parent {
+		path = append(path, n.cell)
+	}
+
+	at, _ := s.pf.Rules.FinishesAt(path[len(path)-1], s.finish)
+
+	return newSolution(s.pf.Grid, path, at)
+}
@@ -0,0 +1,252 @@
+package pathfinder
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBidirectionalPathfinder(t *testing.T) {
+	grid := NewGrid(3, 3)
+
+	got := NewBidirectionalPathfinder(grid, VelocityDistance, Rules{StopAtFinish: true})
+	assert.Equal(t, grid, got.(*BidirectionalPathfinder).Grid)
+	assert.Equal(t, Rules{StopAtFinish: true}, got.(*BidirectionalPathfinder).Rules)
+
+	assert.Nil(t, NewBidirectionalPathfinder(nil, VelocityDistance, Rules{}))
+	assert.Nil(t, NewBidirectionalPathfinder(grid, nil, Rules{}))
+}
+
+func TestBidirectionalPathfinder_FindPath(t *testing.T) {
+	tests := []struct {
+		name   string
+		grid   *Grid
+		rules  Rules
+		start  *Cell
+		finish Goal
+		want   string
+		err    error
+	}{
+		{
+			name:   "valid path found",
+			grid:   NewGrid(3, 3),
+			start:  &Cell{X: 0, Y: 0},
+			finish: NewLandingGoal(&Cell{X: 2, Y: 2}),
+			want:   "(0,0) (1,1) (2,2)",
+		},
+		{
+			name:   "finish reached at full speed",
+			grid:   NewGrid(1, 10),
+			start:  &Cell{X: 0, Y: 0},
+			finish: NewLandingGoal(&Cell{X: 9, Y: 0}),
+			want:   "(0,0) (1,0) (3,0) (6,0) (9,0)",
+		},
+		{
+			name:   "stop at finish",
+			grid:   NewGrid(1, 10),
+			rules:  Rules{StopAtFinish: true},
+			start:  &Cell{X: 0, Y: 0},
+			finish: NewLandingGoal(&Cell{X: 9, Y: 0}),
+			want:   "(0,0) (1,0) (3,0) (6,0) (8,0) (9,0)",
+		},
+		{
+			name:   "finish line crossed",
+			grid:   NewGrid(3, 10),
+			start:  &Cell{X: 0, Y: 1},
+			finish: NewFinishLine(7, 0, 7, 2),
+			want:   "(0,1) (1,0) (2,0) (4,0) (7,0)",
+		},
+		{
+			name:   "start is the finish",
+			grid:   NewGrid(3, 3),
+			start:  &Cell{X: 1, Y: 1},
+			finish: NewLandingGoal(&Cell{X: 1, Y: 1}),
+			want:   "(1,1)",
+		},
+		{
+			name: "no path found",
+			grid: NewGrid(3, 3, []Obstacle{
+				{X1: 1, Y1: 0, X2: 1, Y2: 2},
+				{X1: 0, Y1: 1, X2: 2, Y2: 1},
+			}...),
+			start:  &Cell{X: 0, Y: 0},
+			finish: NewLandingGoal(&Cell{X: 2, Y: 2}),
+		},
+		{
+			name:   "nil input cell",
+			grid:   NewGrid(3, 3),
+			start:  &Cell{X: 0, Y: 0},
+			finish: NewLandingGoal(),
+			err:    errors.New("start and finish cells must be provided"),
+		},
+		{
+			name:   "finish cell not available",
+			grid:   NewGrid(3, 3, Obstacle{X1: 2, Y1: 2, X2: 2, Y2: 2}),
+			start:  &Cell{X: 0, Y: 0},
+			finish: NewLandingGoal(&Cell{X: 2, Y: 2}),
+			err:    errors.New("finish cell is not available"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pf := NewBidirectionalPathfinder(test.grid, VelocityDistance, test.rules)
+			got, err := pf.FindPath(context.Background(), []*Cell{test.start}, test.finish)
+
+			if test.err != nil {
+				assert.ErrorContains(t, err, test.err.Error())
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NoError(t, err)
+			if test.want == "" {
+				assert.Nil(t, got)
+				return
+			}
+			assert.Equal(t, test.want, racePositions(got))
+		})
+	}
+}
+
+// TestBidirectionalPathfinder_FindPath_Reference checks that the bidirectional search
+// finds races of the same cost as the A* algorithm on random tracks with terrain,
+// whatever velocity the finish is reached with.
+func TestBidirectionalPathfinder_FindPath_Reference(t *testing.T) {
+	rules := map[string]Rules{
+		"classic rules":    {},
+		"fast hoppers":     {MaxSpeed: 5, MaxAcceleration: 2},
+		"axis hoppers":     {AxisAcceleration: true},
+		"stop at finish":   {StopAtFinish: true},
+		"flight collision": {FlightCollision: true},
+	}
+
+	for rName, r := range rules {
+		t.Run(rName, func(t *testing.T) {
+			rnd := rand.New(rand.NewSource(1))
+
+			for i := 0; i < 300; i++ {
+				grid, starts, finish := randomTrack(rnd, 10, 10)
+
+				var goal Goal = NewLandingGoal(finish...)
+				if rnd.Intn(3) == 0 {
+					goal = NewFinishLine(finish[0].X, 0, finish[0].X, grid.Rows-1)
+				}
+
+				var terrain []Terrain
+				for n := rnd.Intn(3); n > 0; n-- {
+					x, y := rnd.Intn(grid.Cols), rnd.Intn(grid.Rows)
+					terrain = append(terrain, Terrain{X1: x, X2: x + rnd.Intn(4), Y1: y, Y2: y + rnd.Intn(4), Cost: rnd.Intn(6) - 1})
+				}
+				grid = grid.WithTerrain(terrain...)
+
+				want, wantErr := NewGridPathfinder(grid, VelocityDistance, r).FindPath(context.Background(), starts, goal)
+				got, err := NewBidirectionalPathfinder(grid, VelocityDistance, r).FindPath(context.Background(), starts, goal)
+				assert.Equal(t, wantErr, err)
+
+				assert.Equal(t, want == nil, got == nil, "starts %v, finish %v, terrain %v", starts, goal.Cells(), terrain)
+				if got != nil && want != nil {
+					assert.Equal(t, want.Cost, got.Cost, "starts %v, finish %v, terrain %v", starts, goal.Cells(), terrain)
+					assert.NoError(t, validateRace(grid, r, got, goal))
+				}
+			}
+		})
+	}
+}
+
+func TestBidirectionalPathfinder_FindPath_Timed(t *testing.T) {
+	// the gate is open at odd times only
+	grid := NewGrid(1, 7, Obstacle{X1: 3, X2: 3, Y1: 0, Y2: 0, ActiveFor: 1, Period: 2})
+
+	got, err := NewBidirectionalPathfinder(grid, VelocityDistance, Rules{}).
+		FindPath(context.Background(), []*Cell{{X: 0, Y: 0}}, NewLandingGoal(&Cell{X: 6, Y: 0}))
+	assert.NoError(t, err)
+	assert.Equal(t, "(0,0) (1,0) (2,0) (4,0) (6,0)", racePositions(got))
+}
+
+func TestBidirectionalPathfinder_FindPath_Aborted(t *testing.T) {
+	grid := NewGrid(20, 20, Obstacle{X1: 0, X2: 17, Y1: 10, Y2: 10})
+
+	var stats SearchStats
+	got, err := NewBidirectionalPathfinder(grid, VelocityDistance, Rules{FlightCollision: true}).FindPath(context.Background(),
+		[]*Cell{{X: 0, Y: 0}}, NewLandingGoal(&Cell{X: 0, Y: 19}), WithMaxExpansions(50), WithStats(&stats))
+	assert.Nil(t, got)
+	assert.ErrorIs(t, err, ErrMaxExpansions)
+	assert.Equal(t, 50, stats.Expanded)
+
+	var aborted *AbortedError
+	assert.True(t, errors.As(err, &aborted))
+	assert.NotNil(t, aborted.Partial)
+}
+
+// validateRace returns an error if the race breaks the rules of the game on the given grid
+// or does not finish at the given finish.
+func validateRace(grid *Grid, rules Rules, s *Solution, finish Goal) error {
+	cur := &Cell{X: s.Start.X, Y: s.Start.Y}
+
+	for i, h := range s.Hops {
+		var next *Cell
+		for _, n := range grid.GetNeighbors(cur, rules) {
+			if n.X == h.X && n.Y == h.Y && n.Speed == h.Speed {
+				next = n
+			}
+		}
+		if next == nil {
+			return errors.Errorf("hop %d to %v with speed %v is not allowed", i, Position{X: h.X, Y: h.Y}, h.Speed)
+		}
+
+		if i < len(s.Hops)-1 && rules.Finishes(next, finish) {
+			return errors.Errorf("race is finished by hop %d already", i)
+		}
+
+		cur = next
+	}
+
+	if at, ok := rules.FinishesAt(cur, finish); !ok || at != s.Finish {
+		return errors.Errorf("race does not finish at %v", s.Finish)
+	}
+
+	return nil
+}
+
+func BenchmarkBidirectionalPathfinder_FindPath(b *testing.B) {
+	pathfinders := []struct {
+		name string
+		new  func(*Grid, Heuristic, Rules) Pathfinder
+	}{
+		{name: "astar", new: NewGridPathfinder},
+		{name: "bidirectional", new: NewBidirectionalPathfinder},
+	}
+	rules := []struct {
+		name  string
+		rules Rules
+	}{
+		{name: "classic rules", rules: Rules{}},
+		{name: "stop at finish", rules: Rules{StopAtFinish: true}},
+	}
+
+	// long open tracks crossed from corner to corner
+	for _, length := range []int{100, 1000} {
+		grid := NewGrid(20, length)
+		starts := []*Cell{{X: 0, Y: 0}}
+		finish := NewLandingGoal(&Cell{X: length - 1, Y: 19})
+
+		for _, r := range rules {
+			for _, p := range pathfinders {
+				pf := p.new(grid, VelocityDistance, r.rules)
+
+				b.Run(fmt.Sprintf("%s/%s/20x%d", p.name, r.name, length), func(b *testing.B) {
+					b.ReportAllocs()
+					for i := 0; i < b.N; i++ {
+						if _, err := pf.FindPath(context.Background(), starts, finish); err != nil {
+							b.Fatal(err)
+						}
+					}
+				})
+			}
+		}
+	}
+}
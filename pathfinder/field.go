@@ -0,0 +1,176 @@
+package pathfinder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// unreachable marks the cells of a distance field the hopper cannot land on.
+const unreachable = -1
+
+// DistanceField holds the minimal number of hops a hopper needs
+// to land on each cell of a grid (with any velocity) from the given start cells.
+type DistanceField struct {
+	// Rows is the number of rows in the grid.
+	Rows int
+	// Cols is the number of columns in the grid.
+	Cols int
+
+	// hops holds the minimal number of hops per cell row by row,
+	// or unreachable if the cell cannot be landed on.
+	hops []int
+	// grid is the grid the field is calculated for.
+	grid *Grid
+}
+
+// NewDistanceField returns the distance field of the grid from the given start cells
+// under the given game rules.
+//
+// The field is calculated by the breadth-first search over the states of the hopper,
+// so each cell gets the number of hops of the first state landing on it.
+// The start cells themselves are reached with zero hops.
+func NewDistanceField(grid *Grid, starts []*Cell, rules Rules) (*DistanceField, error) {
+	if grid == nil {
+		return nil, errors.New("grid must be provided")
+	}
+	if len(starts) == 0 {
+		return nil, errors.New("start cells must be provided")
+	}
+
+	f := &DistanceField{
+		Rows: grid.Rows,
+		Cols: grid.Cols,
+		hops: make([]int, grid.Rows*grid.Cols),
+		grid: grid,
+	}
+	for i := range f.hops {
+		f.hops[i] = unreachable
+	}
+
+	// visited holds every state discovered so far
+	visited := make(map[State]struct{})
+
+	var queue []*node
+	for _, start := range starts {
+		if start == nil {
+			return nil, errors.New("start cells must be provided")
+		}
+
+		// the hopper rests on the start cell
+		initial := grid.GetCell(start.X, start.Y)
+		if initial == nil {
+			return nil, errors.New("start cell is out of grid")
+		}
+
+		if _, ok := visited[initial.State()]; ok {
+			continue
+		}
+
+		visited[initial.State()] = struct{}{}
+		f.hops[f.index(initial.X, initial.Y)] = 0
+		queue = append(queue, newNode(initial, nil))
+	}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, next := range grid.GetNeighbors(current.cell, rules) {
+			if _, ok := visited[next.State()]; ok {
+				continue
+			}
+
+			neighbor := newNode(next, current)
+			visited[neighbor.state()] = struct{}{}
+
+			// the first state landing on a cell has the minimal number of hops
+			if i := f.index(next.X, next.Y); f.hops[i] == unreachable {
+				f.hops[i] = neighbor.gCost
+			}
+
+			queue = append(queue, neighbor)
+		}
+	}
+
+	return f, nil
+}
+
+// Hops returns the minimal number of hops needed to land on the cell with the given coordinates.
+// It returns false if the cell is out of the grid or cannot be landed on.
+func (f *DistanceField) Hops(x, y int) (int, bool) {
+	if x < 0 || x >= f.Cols || y < 0 || y >= f.Rows {
+		return 0, false
+	}
+
+	hops := f.hops[f.index(x, y)]
+
+	return hops, hops != unreachable
+}
+
+// Unreachable returns the positions of the available cells the hopper can never land on,
+// row by row.
+func (f *DistanceField) Unreachable() []Position {
+	var positions []Position
+
+	for y := 0; y < f.Rows; y++ {
+		for x := 0; x < f.Cols; x++ {
+			if f.grid.IsAvailable(x, y) && f.hops[f.index(x, y)] == unreachable {
+				positions = append(positions, Position{X: x, Y: y})
+			}
+		}
+	}
+
+	return positions
+}
+
+// String returns the string representation of the distance field:
+// a line per row of the grid with the number of hops per cell,
+// where "#" stands for an obstacle and "." stands for an unreachable cell.
+func (f *DistanceField) String() string {
+	if f == nil {
+		return ""
+	}
+
+	// align the columns by the widest number of hops
+	width := 1
+	for _, hops := range f.hops {
+		if hops != unreachable {
+			width = max(width, len(strconv.Itoa(hops)))
+		}
+	}
+
+	var sb strings.Builder
+
+	for y := 0; y < f.Rows; y++ {
+		if y > 0 {
+			sb.WriteString("\n")
+		}
+
+		for x := 0; x < f.Cols; x++ {
+			if x > 0 {
+				sb.WriteString(" ")
+			}
+
+			i := f.index(x, y)
+
+			switch {
+			case !f.grid.IsAvailable(x, y):
+				sb.WriteString(fmt.Sprintf("%*s", width, "#"))
+			case f.hops[i] == unreachable:
+				sb.WriteString(fmt.Sprintf("%*s", width, "."))
+			default:
+				sb.WriteString(fmt.Sprintf("%*d", width, f.hops[i]))
+			}
+		}
+	}
+
+	return sb.String()
+}
+
+// index returns the index of the cell with the given coordinates in the field.
+func (f *DistanceField) index(x, y int) int {
+	return y*f.Cols + x
+}
@@ -0,0 +1,123 @@
+package pathfinder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDistanceField(t *testing.T) {
+	tests := []struct {
+		name        string
+		grid        *Grid
+		starts      []*Cell
+		rules       Rules
+		want        string
+		unreachable []Position
+		err         error
+	}{
+		{
+			name:        "straight track",
+			grid:        NewGrid(1, 5),
+			starts:      []*Cell{{X: 0, Y: 0}},
+			want:        "0 1 2 2 3",
+			unreachable: nil,
+		},
+		{
+			name:        "several start cells",
+			grid:        NewGrid(1, 5),
+			starts:      []*Cell{{X: 0, Y: 0}, {X: 4, Y: 0}, {X: 0, Y: 0}},
+			want:        "0 1 2 1 0",
+			unreachable: nil,
+		},
+		{
+			name: "cells behind walls",
+			grid: NewGrid(3, 3, []Obstacle{
+				{X1: 1, Y1: 0, X2: 1, Y2: 2},
+				{X1: 0, Y1: 1, X2: 2, Y2: 1},
+			}...),
+			starts:      []*Cell{{X: 0, Y: 0}},
+			want:        "0 # .\n# # #\n. # .",
+			unreachable: []Position{{X: 2, Y: 0}, {X: 0, Y: 2}, {X: 2, Y: 2}},
+		},
+		{
+			name: "wall blocking flights",
+			grid: NewGrid(1, 5, []Obstacle{
+				{X1: 2, Y1: 0, X2: 2, Y2: 0},
+			}...),
+			starts:      []*Cell{{X: 0, Y: 0}},
+			rules:       Rules{FlightCollision: true},
+			want:        "0 1 # . .",
+			unreachable: []Position{{X: 3, Y: 0}, {X: 4, Y: 0}},
+		},
+		{
+			name:   "nil grid",
+			grid:   nil,
+			starts: []*Cell{{X: 0, Y: 0}},
+			err:    errors.New("grid must be provided"),
+		},
+		{
+			name:   "no start cells",
+			grid:   NewGrid(3, 3),
+			starts: nil,
+			err:    errors.New("start cells must be provided"),
+		},
+		{
+			name:   "start cell out of grid",
+			grid:   NewGrid(3, 3),
+			starts: []*Cell{{X: 3, Y: 0}},
+			err:    errors.New("start cell is out of grid"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := NewDistanceField(test.grid, test.starts, test.rules)
+
+			if test.err != nil {
+				assert.ErrorContains(t, err, test.err.Error())
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, got.String())
+			assert.Equal(t, test.unreachable, got.Unreachable())
+		})
+	}
+}
+
+func TestDistanceField_Hops(t *testing.T) {
+	f, err := NewDistanceField(NewGrid(2, 12, Obstacle{X1: 11, Y1: 1, X2: 11, Y2: 1}), []*Cell{{X: 0, Y: 0}}, Rules{})
+	assert.NoError(t, err)
+
+	hops, ok := f.Hops(0, 0)
+	assert.True(t, ok)
+	assert.Equal(t, 0, hops)
+
+	hops, ok = f.Hops(11, 0)
+	assert.True(t, ok)
+	assert.Equal(t, 5, hops)
+
+	_, ok = f.Hops(11, 1)
+	assert.False(t, ok)
+
+	_, ok = f.Hops(12, 0)
+	assert.False(t, ok)
+
+	// the minimal number of hops to land on a cell is the length of the optimal race to it
+	solution, err := NewBreadthFirstPathfinder(NewGrid(2, 12), Rules{}).FindPath(context.Background(), []*Cell{{X: 0, Y: 0}}, NewLandingGoal(&Cell{X: 11, Y: 0}))
+	assert.NoError(t, err)
+	assert.Equal(t, hops, solution.Len())
+}
+
+func TestDistanceField_String(t *testing.T) {
+	f, err := NewDistanceField(NewGrid(1, 30), []*Cell{{X: 0, Y: 0}}, Rules{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, " 0  1  2  2  3  3  3  4  4  4  5  5  5  6  6  6  7  7  7  8  8  8  9  9  9 10 10 10 11 11", f.String())
+
+	var nilField *DistanceField
+	assert.Equal(t, "", nilField.String())
+}
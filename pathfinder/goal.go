@@ -0,0 +1,178 @@
+package pathfinder
+
+import "fmt"
+
+// Goal represents the finish of a race: a set of cells the hopper has to get to.
+type Goal interface {
+	// Cells returns the cells of the finish.
+	Cells() []*Cell
+	// Contains reports whether the cell with the given coordinates belongs to the finish.
+	Contains(x, y int) bool
+	// Reached reports whether the hopper finishes the race with the hop landing on the given cell,
+	// and returns the position of the finish cell the race is finished at.
+	// The cell must hold the velocity the hopper has landed with,
+	// so the departure cell of the hop can be determined as well.
+	Reached(cell *Cell) (Position, bool)
+}
+
+// Position represents the coordinates of a cell.
+type Position struct {
+	X int
+	Y int
+}
+
+// String returns the string representation of the position.
+func (p Position) String() string {
+	return fmt.Sprintf("(%d,%d)", p.X, p.Y)
+}
+
+// cellSet is a set of cells with a fast lookup by coordinates.
+type cellSet struct {
+	cells []*Cell
+	index map[Position]struct{}
+}
+
+// newCellSet returns a new set of the given cells skipping nil and duplicate ones.
+func newCellSet(cells ...*Cell) cellSet {
+	s := cellSet{
+		index: make(map[Position]struct{}, len(cells)),
+	}
+
+	for _, c := range cells {
+		if c == nil {
+			continue
+		}
+
+		s.add(c.X, c.Y)
+	}
+
+	return s
+}
+
+// add adds the cell with the given coordinates to the set.
+func (s *cellSet) add(x, y int) {
+	if _, ok := s.index[Position{X: x, Y: y}]; ok {
+		return
+	}
+
+	s.index[Position{X: x, Y: y}] = struct{}{}
+	s.cells = append(s.cells, &Cell{X: x, Y: y})
+}
+
+// Cells returns the cells of the set.
+func (s cellSet) Cells() []*Cell {
+	return s.cells
+}
+
+// Contains reports whether the cell with the given coordinates belongs to the set.
+func (s cellSet) Contains(x, y int) bool {
+	_, ok := s.index[Position{X: x, Y: y}]
+	return ok
+}
+
+// LandingGoal is a finish reached by landing on any of its cells.
+type LandingGoal struct {
+	cellSet
+}
+
+// NewLandingGoal returns a new finish reached by landing on any of the given cells.
+func NewLandingGoal(cells ...*Cell) *LandingGoal {
+	return &LandingGoal{
+		cellSet: newCellSet(cells...),
+	}
+}
+
+// Reached reports whether the hopper lands on a cell of the finish.
+func (g *LandingGoal) Reached(cell *Cell) (Position, bool) {
+	if cell == nil || !g.Contains(cell.X, cell.Y) {
+		return Position{}, false
+	}
+
+	return Position{X: cell.X, Y: cell.Y}, true
+}
+
+// CrossingGoal is a finish reached by flying over any of its cells (or landing on it).
+type CrossingGoal struct {
+	cellSet
+}
+
+// NewCrossingGoal returns a new finish reached by flying over or landing on any of the given cells.
+func NewCrossingGoal(cells ...*Cell) *CrossingGoal {
+	return &CrossingGoal{
+		cellSet: newCellSet(cells...),
+	}
+}
+
+// NewFinishLine returns a new finish line: a straight segment from cell (x1, y1) to cell (x2, y2).
+//
+// The finish line is reached by any hop crossing it,
+// i.e., the hopper flies over or lands on any of the cells the segment passes through.
+func NewFinishLine(x1, y1, x2, y2 int) *CrossingGoal {
+	g := &CrossingGoal{
+		cellSet: newCellSet(),
+	}
+
+	walkLine(x1, y1, x2, y2, func(x, y int) bool {
+		g.add(x, y)
+		return true
+	})
+
+	return g
+}
+
+// Reached reports whether the hop landing on the given cell passes through a cell of the finish.
+// The first finish cell on the way of the hop is returned.
+func (g *CrossingGoal) Reached(cell *Cell) (Position, bool) {
+	if cell == nil {
+		return Position{}, false
+	}
+
+	// walk the hop until a finish cell is found
+	var at Position
+	reached := !walkLine(cell.X-cell.Speed.X, cell.Y-cell.Speed.Y, cell.X, cell.Y, func(x, y int) bool {
+		at = Position{X: x, Y: y}
+		return !g.Contains(x, y)
+	})
+	if !reached {
+		return Position{}, false
+	}
+
+	return at, true
+}
+
+// Goals is a finish made of several goals (e.g., a finish line together with a pit area).
+// It is reached if any of its goals is reached.
+type Goals []Goal
+
+// Cells returns the cells of all the goals.
+func (gs Goals) Cells() []*Cell {
+	var cells []*Cell
+	for _, g := range gs {
+		cells = append(cells, g.Cells()...)
+	}
+
+	return cells
+}
+
+// Contains reports whether the cell with the given coordinates belongs to any of the goals.
+func (gs Goals) Contains(x, y int) bool {
+	for _, g := range gs {
+		if g.Contains(x, y) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Reached reports whether the hop landing on the given cell reaches any of the goals.
+// The finish cell of the first goal reached is returned.
+func (gs Goals) Reached(cell *Cell) (Position, bool) {
+	for _, g := range gs {
+		if at, ok := g.Reached(cell); ok {
+			return at, true
+		}
+	}
+
+	return Position{}, false
+}
@@ -0,0 +1,202 @@
+package pathfinder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLandingGoal(t *testing.T) {
+	tests := []struct {
+		name  string
+		cells []*Cell
+		want  []*Cell
+	}{
+		{
+			name:  "single cell",
+			cells: []*Cell{{X: 1, Y: 2}},
+			want:  []*Cell{{X: 1, Y: 2}},
+		},
+		{
+			name:  "duplicate and nil cells skipped",
+			cells: []*Cell{{X: 1, Y: 2}, nil, {X: 2, Y: 2}, {X: 1, Y: 2}},
+			want:  []*Cell{{X: 1, Y: 2}, {X: 2, Y: 2}},
+		},
+		{
+			name:  "no cells",
+			cells: nil,
+			want:  nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := NewLandingGoal(test.cells...)
+			assert.Equal(t, test.want, got.Cells())
+		})
+	}
+}
+
+func TestLandingGoal_Reached(t *testing.T) {
+	goal := NewLandingGoal(&Cell{X: 2, Y: 2}, &Cell{X: 3, Y: 2})
+
+	tests := []struct {
+		name string
+		cell *Cell
+		want bool
+		at   Position
+	}{
+		{
+			name: "landed on finish cell",
+			cell: &Cell{X: 3, Y: 2, Speed: Velocity{X: 1, Y: 0}},
+			want: true,
+			at:   Position{X: 3, Y: 2},
+		},
+		{
+			name: "flown over finish cells",
+			cell: &Cell{X: 4, Y: 2, Speed: Velocity{X: 3, Y: 0}},
+			want: false,
+		},
+		{
+			name: "nil cell",
+			cell: nil,
+			want: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			at, ok := goal.Reached(test.cell)
+			assert.Equal(t, test.want, ok)
+			assert.Equal(t, test.at, at)
+		})
+	}
+}
+
+func TestNewFinishLine(t *testing.T) {
+	tests := []struct {
+		name           string
+		x1, y1, x2, y2 int
+		want           []*Cell
+	}{
+		{
+			name: "vertical line",
+			x1:   2, y1: 0, x2: 2, y2: 2,
+			want: []*Cell{{X: 2, Y: 0}, {X: 2, Y: 1}, {X: 2, Y: 2}},
+		},
+		{
+			name: "single cell",
+			x1:   1, y1: 1, x2: 1, y2: 1,
+			want: []*Cell{{X: 1, Y: 1}},
+		},
+		{
+			name: "slanted line",
+			x1:   0, y1: 0, x2: 2, y2: 1,
+			want: []*Cell{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 2, Y: 1}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := NewFinishLine(test.x1, test.y1, test.x2, test.y2)
+			assert.Equal(t, test.want, got.Cells())
+			for _, c := range test.want {
+				assert.True(t, got.Contains(c.X, c.Y))
+			}
+		})
+	}
+}
+
+func TestCrossingGoal_Reached(t *testing.T) {
+	line := NewFinishLine(2, 0, 2, 2)
+
+	tests := []struct {
+		name string
+		goal Goal
+		cell *Cell
+		want bool
+		at   Position
+	}{
+		{
+			name: "landed on finish line",
+			goal: line,
+			cell: &Cell{X: 2, Y: 1, Speed: Velocity{X: 1, Y: 0}},
+			want: true,
+			at:   Position{X: 2, Y: 1},
+		},
+		{
+			name: "flown over finish line",
+			goal: line,
+			cell: &Cell{X: 3, Y: 1, Speed: Velocity{X: 3, Y: 0}},
+			want: true,
+			at:   Position{X: 2, Y: 1},
+		},
+		{
+			name: "finish line not reached",
+			goal: line,
+			cell: &Cell{X: 1, Y: 1, Speed: Velocity{X: 1, Y: 1}},
+			want: false,
+		},
+		{
+			name: "flown along finish line",
+			goal: NewCrossingGoal(&Cell{X: 1, Y: 1}),
+			cell: &Cell{X: 2, Y: 2, Speed: Velocity{X: 2, Y: 2}},
+			want: true,
+			at:   Position{X: 1, Y: 1},
+		},
+		{
+			name: "nil cell",
+			goal: line,
+			cell: nil,
+			want: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			at, ok := test.goal.Reached(test.cell)
+			assert.Equal(t, test.want, ok)
+			assert.Equal(t, test.at, at)
+		})
+	}
+}
+
+func TestGoals(t *testing.T) {
+	goals := Goals{
+		NewFinishLine(4, 0, 4, 2),
+		NewLandingGoal(&Cell{X: 1, Y: 2}, &Cell{X: 2, Y: 2}),
+	}
+
+	assert.Equal(t, []*Cell{{X: 4, Y: 0}, {X: 4, Y: 1}, {X: 4, Y: 2}, {X: 1, Y: 2}, {X: 2, Y: 2}}, goals.Cells())
+	assert.True(t, goals.Contains(4, 1))
+	assert.True(t, goals.Contains(2, 2))
+	assert.False(t, goals.Contains(3, 2))
+
+	tests := []struct {
+		name string
+		cell *Cell
+		want bool
+		at   Position
+	}{
+		{
+			name: "finish line crossed",
+			cell: &Cell{X: 5, Y: 0, Speed: Velocity{X: 2, Y: 0}},
+			want: true,
+			at:   Position{X: 4, Y: 0},
+		},
+		{
+			name: "landed in pit area",
+			cell: &Cell{X: 1, Y: 2, Speed: Velocity{X: -1, Y: 0}},
+			want: true,
+			at:   Position{X: 1, Y: 2},
+		},
+		{
+			name: "flown over pit area",
+			cell: &Cell{X: 3, Y: 2, Speed: Velocity{X: 3, Y: 0}},
+			want: false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			at, ok := goals.Reached(test.cell)
+			assert.Equal(t, test.want, ok)
+			assert.Equal(t, test.at, at)
+		})
+	}
+}
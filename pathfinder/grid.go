@@ -1,102 +1,187 @@
 package pathfinder
 
-const (
-	minimalSpeed = -3
-	maximalSpeed = 3
-)
+import "fmt"
 
 // Velocity represents the speed of a hopper.
 type Velocity struct {
 	// X is the horizontal speed.
-	// It can be in the range from -3 to 3 inclusive.
+	// Its absolute value is limited by the game rules (3 by default).
 	X int
 	// Y is the vertical speed.
-	// It can be in the range from -3 to 3 inclusive.
+	// Its absolute value is limited by the game rules (3 by default).
 	Y int
 }
 
+// String returns the string representation of the velocity.
+func (v Velocity) String() string {
+	return fmt.Sprintf("(%d,%d)", v.X, v.Y)
+}
+
+// State represents a state of a hopper during the race:
+// the cell the hopper has landed on and the velocity it has reached the cell with.
+//
+// Two hoppers standing on the same cell but moving with different velocities
+// are in different states, as they are able to make different hops.
+type State struct {
+	// X is the horizontal coordinate of the cell.
+	X int
+	// Y is the vertical coordinate of the cell.
+	Y int
+	// Speed is the velocity of the hopper on the cell.
+	Speed Velocity
+	// Time is the time the hopper has landed on the cell at (see Cell.Time).
+	Time int
+}
+
 // Cell represents a cell in a grid.
+//
+// Cells of a grid describe the track itself (i.e., cell availability) and are never modified
+// by the path finding algorithms, so a single grid can serve any number of searches,
+// including concurrent ones. The search bookkeeping of each state is held by the algorithms separately.
+//
+// A cell is also used to describe a hopper standing on it: such a cell holds the velocity
+// the hopper has landed with (e.g., the neighbors returned by Grid.GetNeighbors).
 type Cell struct {
 	// X is the horizontal coordinate of the cell.
 	X int
 	// Y is the vertical coordinate of the cell.
 	Y int
 
-	// Open indicates whether the cell has been checked and evaluated
-	// during path finding algorithm iteration.
-	Open bool
-	// Closed indicates whether the cell has been processed
-	// during path finding algorithm iteration.
-	Closed bool
 	// Available indicates whether the cell is available for hopping.
 	Available bool
 
-	// GCost is the cost of the path from the start cell to this cell.
-	// In the case of Hopping Race game, it is the number of hops from the start cell to this cell.
-	GCost int
-	// HCost is the heuristic cost of the path from this cell to the finish cell.
-	// HCost is calculated by the heuristic function
-	// and represents the estimated number of 1-step hops from this cell to the finish cell
-	// on a clear grid.
-	HCost int
-	// FCost is the sum of GCost and HCost.
-	FCost int
-
-	// Speed is the speed of the hopper when it reaches this cell.
+	// Speed is the velocity of the hopper standing on the cell.
+	// It is always zero for the cells of a grid.
 	Speed Velocity
+	// Time is the number of hops the hopper has made to land on the cell.
+	// It is tracked on the grids with timed obstacles only (and is always zero otherwise),
+	// and it is folded onto the period of the obstacles once they change periodically only,
+	// so the hoppers landing on the cell at the times with the same obstacles are in the same state.
+	Time int
+}
 
-	// Parent is the cell from which the hopper reached this cell.
-	Parent *Cell
+// State returns the state of a hopper standing on the cell.
+func (c *Cell) State() State {
+	return State{X: c.X, Y: c.Y, Speed: c.Speed, Time: c.Time}
 }
 
 // Obstacle represents an area in a grid that is not available for hopping.
+//
+// An obstacle is active all the time unless it has a schedule: a timed obstacle is active
+// for ActiveFor hops since hop ActiveFrom (or forever, if ActiveFor is zero), and the window
+// repeats every Period hops if Period is set. The time of a hop is the number of hops made
+// including the hop itself, so the hopper landing with the first hop checks the obstacles active at time 1.
+// Moving obstacles are described by several timed obstacles active in turns.
 type Obstacle struct {
 	X1 int
 	X2 int
 	Y1 int
 	Y2 int
+
+	// ActiveFrom is the first time the obstacle is active at.
+	ActiveFrom int
+	// ActiveFor is the number of hops the obstacle stays active for;
+	// zero keeps the obstacle active forever.
+	ActiveFor int
+	// Period is the number of hops the active window of the obstacle repeats after;
+	// zero makes the window happen once.
+	Period int
 }
 
+// maxDenseGridCells is the maximal number of cells of a grid storing its cells as a bitset.
+// The larger grids index their obstacles instead.
+const maxDenseGridCells = 1 << 20
+
 // Grid represents an area in which hoppers can move.
+//
+// A grid is not modified once created, so it can be safely queried
+// by any number of pathfinders at once.
+//
+// The availability of the cells of a grid up to maxDenseGridCells cells is stored as a bitset
+// of the occupied cells laid out row by row, so a grid takes a single bit per cell
+// and the cells are addressed by index arithmetic.
+// The larger grids keep a sparse index of their obstacles instead,
+// so the memory they take depends on the number of obstacles rather than on the size of the grid.
+//
+// Landing on any cell of a grid costs 1 unless the grid is given terrain (see Grid.WithTerrain).
 type Grid struct {
-	// Cells is a map of cells in the grid.
-	Cells map[int]map[int]*Cell
-
 	// Rows is the number of rows in the grid.
 	Rows int
 	// Cols is the number of columns in the grid.
 	Cols int
+
+	// occupied is the bitset of the cells that are not available for hopping;
+	// it is nil if the grid is sparse.
+	occupied []uint64
+	// obstacles is the index of the obstacles of a sparse grid;
+	// it is nil if the grid is dense.
+	obstacles *obstacleIndex
+	// timeline holds the timed obstacles of the grid;
+	// it is nil if all the obstacles are active all the time.
+	timeline *timeline
+
+	// terrain holds the areas changing the landing costs, clamped to the grid.
+	terrain []Terrain
+	// landingCosts are the costs of landing on the cells of a dense grid with terrain laid out row by row;
+	// it is nil if the grid is sparse or has no terrain.
+	landingCosts []int32
+	// minLandingCost is the minimal cost of landing on a cell of the grid with terrain.
+	minLandingCost int
 }
 
 // NewGrid returns a new grid with the given number of rows and columns
 // and the specified obstacles.
+//
+// The parts of the obstacles out of the grid are ignored.
+// The obstacles active all the time are stored in the bitset or the index of the grid,
+// while the timed obstacles are kept aside and looked up for each time they are checked at.
 func NewGrid(rows, cols int, obstacles ...Obstacle) *Grid {
+	return newGrid(rows, cols, rows*cols > maxDenseGridCells, obstacles...)
+}
+
+// newGrid returns a new grid with the given number of rows and columns and the specified obstacles,
+// either sparse or dense.
+func newGrid(rows, cols int, sparse bool, obstacles ...Obstacle) *Grid {
 	if rows <= 0 || cols <= 0 {
 		return nil
 	}
 
 	g := &Grid{
-		Cells: make(map[int]map[int]*Cell),
-		Rows:  rows,
-		Cols:  cols,
+		Rows: rows,
+		Cols: cols,
 	}
 
-	for i := 0; i < rows; i++ {
-		g.Cells[i] = make(map[int]*Cell)
-		for j := 0; j < cols; j++ {
-			g.Cells[i][j] = &Cell{
-				X:         j,
-				Y:         i,
-				Available: true,
-			}
+	// clamp obstacles to the grid
+	clamped := make([]Obstacle, 0, len(obstacles))
+	var timed []Obstacle
+	for _, o := range obstacles {
+		o.X1, o.X2, o.Y1, o.Y2 = max(o.X1, 0), min(o.X2, cols-1), max(o.Y1, 0), min(o.Y2, rows-1)
+		switch {
+		case o.X1 > o.X2 || o.Y1 > o.Y2:
+			continue
+		case o.timed():
+			timed = append(timed, o)
+		default:
+			clamped = append(clamped, o)
 		}
 	}
 
+	if len(timed) > 0 {
+		g.timeline = newTimeline(timed)
+	}
+
+	if sparse {
+		g.obstacles = newObstacleIndex(clamped)
+		return g
+	}
+
 	// place obstacles
-	for _, o := range obstacles {
-		for i := o.Y1; i <= o.Y2; i++ {
-			for j := o.X1; j <= o.X2; j++ {
-				g.Cells[i][j].Available = false
+	g.occupied = make([]uint64, (rows*cols+63)/64)
+	for _, o := range clamped {
+		for y := o.Y1; y <= o.Y2; y++ {
+			for x := o.X1; x <= o.X2; x++ {
+				i := g.index(x, y)
+				g.occupied[i/64] |= 1 << (i % 64)
 			}
 		}
 	}
@@ -105,65 +190,122 @@ func NewGrid(rows, cols int, obstacles ...Obstacle) *Grid {
 }
 
 // GetCell returns the cell at the specified coordinates.
+//
+// The cell is created on each call, so changing it does not affect the grid.
+// If the coordinates are out of the grid, nil is returned.
 func (g *Grid) GetCell(x, y int) *Cell {
-	if x < 0 || x >= g.Cols || y < 0 || y >= g.Rows {
+	if !g.contains(x, y) {
 		return nil
 	}
 
-	return g.Cells[y][x]
+	return &Cell{
+		X:         x,
+		Y:         y,
+		Available: !g.isOccupied(x, y),
+	}
+}
+
+// IsAvailable reports whether the cell at the specified coordinates is available for hopping.
+// The cells out of the grid are not available.
+//
+// Only the obstacles active all the time are taken into account (see Grid.IsAvailableAt for the timed ones).
+func (g *Grid) IsAvailable(x, y int) bool {
+	return g.contains(x, y) && !g.isOccupied(x, y)
+}
+
+// contains reports whether the specified coordinates are inside the grid.
+func (g *Grid) contains(x, y int) bool {
+	return x >= 0 && x < g.Cols && y >= 0 && y < g.Rows
+}
+
+// index returns the index of the cell at the specified coordinates in the grid bitset.
+func (g *Grid) index(x, y int) int {
+	return y*g.Cols + x
+}
+
+// isOccupied reports whether the cell at the specified coordinates inside the grid is covered by an obstacle.
+func (g *Grid) isOccupied(x, y int) bool {
+	if g.obstacles != nil {
+		return g.obstacles.isOccupied(x, y)
+	}
+
+	i := g.index(x, y)
+
+	return g.occupied[i/64]&(1<<(i%64)) != 0
 }
 
-// GetNeighbors returns the available neighboring cells of the specified cell.
+// GetNeighbors returns the states a hopper can reach with a single hop from the specified cell.
 //
-// Neighboring cells are cells that are horizontally, vertically, or diagonally adjacent to the specified cell.
 // The speed of the hopper is taken into account when determining the neighbors: the hopper can move in any direction
-// keeping its speed and considering a possible velocity change by -1, 0, or 1
-// (but gaining the speed not less than -3 and not higher than 3 in each direction).
+// keeping its speed and considering a possible velocity change allowed by the given rules
+// (by -1, 0, or 1 in both directions by default, but gaining the speed not less than -3 and not higher than 3).
+//
+// Each neighbor is returned as a new cell holding the landing coordinates
+// and the velocity the hopper lands with, so the same grid cell may be returned
+// for different velocities by different calls.
 //
-// Cells are considered available if they are not obstacles and have not been closed.
-func (g *Grid) GetNeighbors(cell *Cell) []*Cell {
-	if cell == nil {
+// Only the cells that are not obstacles are considered available for landing.
+// If the rules enable flight collisions, the cells the hopper flies over must be available as well.
+// If the grid has timed obstacles, the neighbors land one hop later than the given cell,
+// and the cells are checked against the obstacles active at that time.
+func (g *Grid) GetNeighbors(cell *Cell, rules Rules) []*Cell {
+	if cell == nil || !g.contains(cell.X, cell.Y) {
 		return nil
 	}
 
-	// determine the range of possible speeds at this moment
-	fromY := cell.Speed.Y - 1
-	if fromY < minimalSpeed {
-		fromY = minimalSpeed
+	available, time := g.IsAvailable, 0
+	if g.timeline != nil {
+		time = g.timeline.clock(cell.Time + 1)
+		available = func(x, y int) bool {
+			return g.IsAvailableAt(x, y, time)
+		}
 	}
 
-	toY := cell.Speed.Y + 1
-	if toY > maximalSpeed {
-		toY = maximalSpeed
-	}
+	maxSpeed := rules.GetMaxSpeed()
 
-	fromX := cell.Speed.X - 1
-	if fromX < minimalSpeed {
-		fromX = minimalSpeed
-	}
+	accelerations := rules.Accelerations()
 
-	toX := cell.Speed.X + 1
-	if toX > maximalSpeed {
-		toX = maximalSpeed
-	}
+	// find available neighbors;
+	// the neighbor cells are allocated at once to reduce allocations
+	cells := make([]Cell, 0, len(accelerations))
+	neighbors := make([]*Cell, 0, len(accelerations))
 
-	// find available neighbors
-	var neighbors []*Cell
+	for _, a := range accelerations {
+		speed := Velocity{X: cell.Speed.X + a.X, Y: cell.Speed.Y + a.Y}
 
-	for i := fromY; i <= toY; i++ {
-		for j := fromX; j <= toX; j++ {
-			// skip the current cell
-			if i == 0 && j == 0 {
-				continue
-			}
+		// skip the speed out of the allowed range
+		if speed.X < -maxSpeed || speed.X > maxSpeed || speed.Y < -maxSpeed || speed.Y > maxSpeed {
+			continue
+		}
 
-			x := cell.X + j
-			y := cell.Y + i
+		// skip the current cell
+		if speed.X == 0 && speed.Y == 0 {
+			continue
+		}
 
-			if c := g.GetCell(x, y); c != nil && c.Available && !c.Closed {
-				neighbors = append(neighbors, c)
-			}
+		x := cell.X + speed.X
+		y := cell.Y + speed.Y
+
+		if !available(x, y) {
+			continue
+		}
+
+		if rules.FlightCollision && !clearFlight(cell.X, cell.Y, x, y, available) {
+			continue
 		}
+
+		cells = append(cells, Cell{
+			X:         x,
+			Y:         y,
+			Available: true,
+			Speed:     speed,
+			Time:      time,
+		})
+		neighbors = append(neighbors, &cells[len(cells)-1])
+	}
+
+	if len(neighbors) == 0 {
+		return nil
 	}
 
 	return neighbors
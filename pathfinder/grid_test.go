@@ -1,6 +1,10 @@
 package pathfinder
 
 import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -15,7 +19,7 @@ func TestNewGrid(t *testing.T) {
 	tests := []struct {
 		name string
 		in   input
-		want *Grid
+		want []string
 	}{
 		{
 			name: "2x2 grid with no obstacles",
@@ -23,19 +27,9 @@ func TestNewGrid(t *testing.T) {
 				rows: 2,
 				cols: 2,
 			},
-			want: &Grid{
-				Rows: 2,
-				Cols: 2,
-				Cells: map[int]map[int]*Cell{
-					0: {
-						0: {X: 0, Y: 0, Available: true},
-						1: {X: 1, Y: 0, Available: true},
-					},
-					1: {
-						0: {X: 0, Y: 1, Available: true},
-						1: {X: 1, Y: 1, Available: true},
-					},
-				},
+			want: []string{
+				"..",
+				"..",
 			},
 		},
 		{
@@ -48,27 +42,40 @@ func TestNewGrid(t *testing.T) {
 					{X1: 0, Y1: 1, X2: 2, Y2: 1},
 				},
 			},
-			want: &Grid{
-				Rows: 3,
-				Cols: 3,
-				Cells: map[int]map[int]*Cell{
-					0: {
-						0: {X: 0, Y: 0, Available: true},
-						1: {X: 1, Y: 0, Available: false},
-						2: {X: 2, Y: 0, Available: true},
-					},
-					1: {
-						0: {X: 0, Y: 1, Available: false},
-						1: {X: 1, Y: 1, Available: false},
-						2: {X: 2, Y: 1, Available: false},
-					},
-					2: {
-						0: {X: 0, Y: 2, Available: true},
-						1: {X: 1, Y: 2, Available: false},
-						2: {X: 2, Y: 2, Available: true},
-					},
+			want: []string{
+				".#.",
+				"###",
+				".#.",
+			},
+		},
+		{
+			name: "obstacle partially out of grid",
+			in: input{
+				rows: 2,
+				cols: 3,
+				obstacles: []Obstacle{
+					{X1: 2, Y1: -1, X2: 4, Y2: 0},
 				},
 			},
+			want: []string{
+				"..#",
+				"...",
+			},
+		},
+		{
+			name: "grid larger than a single bitset word",
+			in: input{
+				rows: 3,
+				cols: 30,
+				obstacles: []Obstacle{
+					{X1: 3, Y1: 2, X2: 29, Y2: 2},
+				},
+			},
+			want: []string{
+				"..............................",
+				"..............................",
+				"...###########################",
+			},
 		},
 		{
 			name: "invalid input",
@@ -81,62 +88,261 @@ func TestNewGrid(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			got := NewGrid(test.in.rows, test.in.cols, test.in.obstacles...)
-			assert.Equal(t, test.want, got)
-		})
+		for _, sparse := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s (sparse: %t)", test.name, sparse), func(t *testing.T) {
+				got := newGrid(test.in.rows, test.in.cols, sparse, test.in.obstacles...)
+				if test.want == nil {
+					assert.Nil(t, got)
+					return
+				}
+
+				assert.Equal(t, len(test.want), got.Rows)
+				assert.Equal(t, len(test.want[0]), got.Cols)
+				assert.Equal(t, test.want, gridMap(got))
+			})
+		}
 	}
 }
 
+func TestNewGrid_Sparse(t *testing.T) {
+	// a large grid indexes its obstacles instead of storing its cells
+	grid := NewGrid(5000, 5000, Obstacle{X1: 10, Y1: 0, X2: 10, Y2: 4990}, Obstacle{X1: 0, Y1: 4999, X2: 4999, Y2: 4999})
+	assert.Nil(t, grid.occupied)
+	assert.NotNil(t, grid.obstacles)
+
+	assert.True(t, grid.IsAvailable(9, 100))
+	assert.False(t, grid.IsAvailable(10, 100))
+	assert.True(t, grid.IsAvailable(10, 4991))
+	assert.False(t, grid.IsAvailable(2500, 4999))
+	assert.False(t, grid.IsAvailable(5000, 0))
+
+	// a small grid stores its cells
+	grid = NewGrid(30, 30)
+	assert.NotNil(t, grid.occupied)
+	assert.Nil(t, grid.obstacles)
+}
+
+func TestNewGrid_SparseMatchesDense(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 50; i++ {
+		rows, cols := 1+r.Intn(40), 1+r.Intn(40)
+
+		var obstacles []Obstacle
+		for j := r.Intn(10); j > 0; j-- {
+			x1, y1 := r.Intn(cols+4)-2, r.Intn(rows+4)-2
+			obstacles = append(obstacles, Obstacle{X1: x1, Y1: y1, X2: x1 + r.Intn(10), Y2: y1 + r.Intn(10)})
+		}
+
+		dense := newGrid(rows, cols, false, obstacles...)
+		sparse := newGrid(rows, cols, true, obstacles...)
+
+		assert.Equal(t, gridMap(dense), gridMap(sparse), "obstacles: %v", obstacles)
+	}
+}
+
+// gridMap returns the rows of the grid, where "." stands for an available cell
+// and "#" stands for an obstacle.
+func gridMap(g *Grid) []string {
+	rows := make([]string, 0, g.Rows)
+
+	for y := 0; y < g.Rows; y++ {
+		var row strings.Builder
+		for x := 0; x < g.Cols; x++ {
+			if g.IsAvailable(x, y) {
+				row.WriteString(".")
+			} else {
+				row.WriteString("#")
+			}
+		}
+		rows = append(rows, row.String())
+	}
+
+	return rows
+}
+
 func TestGrid_GetCell(t *testing.T) {
 	grid := NewGrid(3, 3)
 	cell := grid.GetCell(1, 1)
 	assert.Equal(t, &Cell{X: 1, Y: 1, Available: true}, cell)
 
+	// the grid is not affected by the changes of its cells
+	cell.Available = false
+	assert.True(t, grid.IsAvailable(1, 1))
+
 	cell = grid.GetCell(3, 3)
 	assert.Nil(t, cell)
 }
 
+func TestGrid_IsAvailable(t *testing.T) {
+	grid := NewGrid(2, 2, Obstacle{X1: 1, Y1: 1, X2: 1, Y2: 1})
+
+	assert.True(t, grid.IsAvailable(0, 0))
+	assert.False(t, grid.IsAvailable(1, 1))
+	assert.False(t, grid.IsAvailable(-1, 0))
+	assert.False(t, grid.IsAvailable(0, 2))
+}
+
 func TestGrid_GetNeighbors(t *testing.T) {
 	type input struct {
-		x, y int
+		x, y  int
+		speed Velocity
 	}
 
 	tests := []struct {
-		name string
-		grid *Grid
-		cell input
-		want []*Cell
+		name  string
+		grid  *Grid
+		cell  input
+		rules Rules
+		want  []*Cell
 	}{
 		{
 			name: "valid neighbors",
-			grid: func() *Grid {
-				grid := NewGrid(5, 5, []Obstacle{
-					{X1: 1, Y1: 2, X2: 4, Y2: 3},
-				}...)
-				grid.GetCell(4, 0).Speed = Velocity{X: 0, Y: 0}
-				grid.GetCell(4, 1).Closed = true
-				grid.GetCell(3, 1).Speed = Velocity{X: -1, Y: 1}
-				return grid
-			}(),
-			cell: input{3, 1},
+			grid: NewGrid(5, 5, []Obstacle{
+				{X1: 1, Y1: 2, X2: 4, Y2: 3},
+			}...),
+			cell: input{3, 1, Velocity{X: -1, Y: 1}},
 			want: []*Cell{
-				{X: 2, Y: 1, Available: true},
-				{X: 1, Y: 1, Available: true},
+				{X: 2, Y: 1, Available: true, Speed: Velocity{X: -1, Y: 0}},
+				{X: 1, Y: 1, Available: true, Speed: Velocity{X: -2, Y: 0}},
+			},
+		},
+		{
+			name: "neighbors limited by maximal speed",
+			grid: NewGrid(1, 10),
+			cell: input{3, 0, Velocity{X: 3, Y: 0}},
+			want: []*Cell{
+				{X: 5, Y: 0, Available: true, Speed: Velocity{X: 2, Y: 0}},
+				{X: 6, Y: 0, Available: true, Speed: Velocity{X: 3, Y: 0}},
+			},
+		},
+		{
+			name:  "neighbors limited by custom maximal speed",
+			grid:  NewGrid(1, 10),
+			cell:  input{3, 0, Velocity{X: 3, Y: 0}},
+			rules: Rules{MaxSpeed: 5, MaxAcceleration: 2},
+			want: []*Cell{
+				{X: 4, Y: 0, Available: true, Speed: Velocity{X: 1, Y: 0}},
+				{X: 5, Y: 0, Available: true, Speed: Velocity{X: 2, Y: 0}},
+				{X: 6, Y: 0, Available: true, Speed: Velocity{X: 3, Y: 0}},
+				{X: 7, Y: 0, Available: true, Speed: Velocity{X: 4, Y: 0}},
+				{X: 8, Y: 0, Available: true, Speed: Velocity{X: 5, Y: 0}},
+			},
+		},
+		{
+			name:  "neighbors with axis acceleration",
+			grid:  NewGrid(3, 3),
+			cell:  input{1, 1, Velocity{}},
+			rules: Rules{AxisAcceleration: true},
+			want: []*Cell{
+				{X: 1, Y: 0, Available: true, Speed: Velocity{X: 0, Y: -1}},
+				{X: 0, Y: 1, Available: true, Speed: Velocity{X: -1, Y: 0}},
+				{X: 2, Y: 1, Available: true, Speed: Velocity{X: 1, Y: 0}},
+				{X: 1, Y: 2, Available: true, Speed: Velocity{X: 0, Y: 1}},
+			},
+		},
+		{
+			name: "neighbors flying over obstacles",
+			grid: NewGrid(3, 3, Obstacle{X1: 1, Y1: 1, X2: 1, Y2: 1}),
+			cell: input{0, 0, Velocity{X: 1, Y: 1}},
+			want: []*Cell{
+				{X: 1, Y: 0, Available: true, Speed: Velocity{X: 1, Y: 0}},
+				{X: 2, Y: 0, Available: true, Speed: Velocity{X: 2, Y: 0}},
+				{X: 0, Y: 1, Available: true, Speed: Velocity{X: 0, Y: 1}},
+				{X: 2, Y: 1, Available: true, Speed: Velocity{X: 2, Y: 1}},
+				{X: 0, Y: 2, Available: true, Speed: Velocity{X: 0, Y: 2}},
+				{X: 1, Y: 2, Available: true, Speed: Velocity{X: 1, Y: 2}},
+				{X: 2, Y: 2, Available: true, Speed: Velocity{X: 2, Y: 2}},
+			},
+		},
+		{
+			name:  "neighbors colliding with obstacles in flight",
+			grid:  NewGrid(3, 3, Obstacle{X1: 1, Y1: 1, X2: 1, Y2: 1}),
+			cell:  input{0, 0, Velocity{X: 1, Y: 1}},
+			rules: Rules{FlightCollision: true},
+			want: []*Cell{
+				{X: 1, Y: 0, Available: true, Speed: Velocity{X: 1, Y: 0}},
+				{X: 2, Y: 0, Available: true, Speed: Velocity{X: 2, Y: 0}},
+				{X: 0, Y: 1, Available: true, Speed: Velocity{X: 0, Y: 1}},
+				{X: 0, Y: 2, Available: true, Speed: Velocity{X: 0, Y: 2}},
 			},
 		},
 		{
 			name: "invalid input",
 			grid: NewGrid(3, 3),
-			cell: input{3, 3},
+			cell: input{3, 3, Velocity{}},
 			want: nil,
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			got := test.grid.GetNeighbors(test.grid.GetCell(test.cell.x, test.cell.y))
+			got := test.grid.GetNeighbors(&Cell{X: test.cell.x, Y: test.cell.y, Speed: test.cell.speed}, test.rules)
 			assert.ElementsMatch(t, test.want, got)
 		})
 	}
 }
+
+// benchmarkSizes are the sizes of the square grids the benchmarks are run on.
+var benchmarkSizes = []int{30, 300, 1000}
+
+// benchmarkObstacles returns walls across a square grid of the given size
+// leaving a gap at alternating ends, so the hopper has to zigzag through the grid.
+func benchmarkObstacles(size int) []Obstacle {
+	var obstacles []Obstacle
+
+	for x, i := size/5, 0; x < size-1; x, i = x+size/5, i+1 {
+		if i%2 == 0 {
+			obstacles = append(obstacles, Obstacle{X1: x, X2: x, Y1: 0, Y2: size - size/10 - 1})
+		} else {
+			obstacles = append(obstacles, Obstacle{X1: x, X2: x, Y1: size / 10, Y2: size - 1})
+		}
+	}
+
+	return obstacles
+}
+
+func BenchmarkNewGrid(b *testing.B) {
+	for _, size := range append(benchmarkSizes, 5000) {
+		obstacles := benchmarkObstacles(size)
+
+		b.Run(fmt.Sprintf("%dx%d", size, size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				NewGrid(size, size, obstacles...)
+			}
+		})
+	}
+}
+
+func BenchmarkGrid_GetNeighbors(b *testing.B) {
+	for _, size := range append(benchmarkSizes, 5000) {
+		grid := NewGrid(size, size, benchmarkObstacles(size)...)
+		cell := &Cell{X: size / 2, Y: size / 2, Speed: Velocity{X: 1, Y: -1}}
+
+		b.Run(fmt.Sprintf("%dx%d", size, size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				grid.GetNeighbors(cell, Rules{FlightCollision: true})
+			}
+		})
+	}
+}
+
+func BenchmarkGridPathfinder_FindPath(b *testing.B) {
+	for _, size := range []int{30, 100} {
+		grid := NewGrid(size, size, benchmarkObstacles(size)...)
+		pf := NewGridPathfinder(grid, VelocityDistance, Rules{FlightCollision: true})
+		starts := []*Cell{{X: 0, Y: 0}}
+		finish := NewLandingGoal(&Cell{X: size - 1, Y: size - 1})
+
+		b.Run(fmt.Sprintf("%dx%d", size, size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := pf.FindPath(context.Background(), starts, finish); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package pathfinder
 
+import "github.com/pkg/errors"
+
 // ChebyshevDistance returns the Chebyshev distance between two cells.
 //
 // Chebyshev distance is a special case of Diagonal distance
@@ -17,7 +19,10 @@ package pathfinder
 //	max(dx, dy)
 //
 // because D = D2 = 1.
-func ChebyshevDistance(a, b *Cell) int {
+//
+// Please note that the distance counts 1-cell hops only, while the hopper is able to cover
+// up to 3 cells with a single hop, so the distance may overestimate the number of hops.
+func ChebyshevDistance(a, b *Cell, _ Rules) int {
 	if a == nil || b == nil {
 		return 0
 	}
@@ -34,3 +39,99 @@ func ChebyshevDistance(a, b *Cell) int {
 
 	return max(dx, dy)
 }
+
+// VelocityDistance returns the minimal number of hops needed to get from cell a to cell b
+// on a clear grid, taking into account the speed of the hopper at cell a and the game rules.
+//
+// The hopper accelerates along each axis independently, so the number of hops is estimated
+// for both axes separately as the length of the fastest 1-D acceleration profile:
+// the hopper gains the maximal acceleration on every hop (up to the maximal speed)
+// until the covered distance is not less than the distance to cell b.
+// The estimate for the whole move is the maximum of the estimates for both axes.
+//
+// Unlike ChebyshevDistance, the estimate never exceeds the real number of hops,
+// which makes it an admissible (and also consistent) heuristic for the A* algorithm.
+func VelocityDistance(a, b *Cell, r Rules) int {
+	if a == nil || b == nil {
+		return 0
+	}
+
+	maxSpeed, maxAcceleration := r.GetMaxSpeed(), r.GetMaxAcceleration()
+
+	return max(
+		axisHops(b.X-a.X, a.Speed.X, maxSpeed, maxAcceleration),
+		axisHops(b.Y-a.Y, a.Speed.Y, maxSpeed, maxAcceleration),
+	)
+}
+
+// axisHops returns the minimal number of hops needed to cover the given distance
+// along a single axis starting with the given speed.
+func axisHops(distance, speed, maxSpeed, maxAcceleration int) int {
+	// mirror the axis so the distance is always covered in the positive direction
+	if distance < 0 {
+		distance, speed = -distance, -speed
+	}
+
+	hops, covered := 0, 0
+	for covered < distance {
+		speed = min(speed+maxAcceleration, maxSpeed)
+		covered += speed
+		hops++
+	}
+
+	return hops
+}
+
+// VerifyHeuristic checks whether the heuristic is admissible for the given grid, finish and rules,
+// i.e., it never overestimates the number of hops needed to finish the race.
+//
+// The heuristic is checked to be consistent for every state of the grid:
+// the estimate of the number of hops needed to finish the race (which is zero for the finishing states,
+// and the minimal estimate over all the finish cells for others) decreases by no more than one
+// (the cost of a hop) on every hop the hopper can make.
+// A consistent heuristic is admissible as well.
+//
+// An error describing the first violation found is returned if the check fails.
+func VerifyHeuristic(grid *Grid, h Heuristic, finish Goal, rules Rules) error {
+	if grid == nil || h == nil || finish == nil {
+		return errors.New("grid, heuristic and finish must be provided")
+	}
+
+	for _, c := range finish.Cells() {
+		if grid.GetCell(c.X, c.Y) == nil {
+			return errors.New("finish cell is out of grid")
+		}
+	}
+
+	maxSpeed := rules.GetMaxSpeed()
+
+	for y := 0; y < grid.Rows; y++ {
+		for x := 0; x < grid.Cols; x++ {
+			if !grid.IsAvailable(x, y) {
+				continue
+			}
+
+			for vy := -maxSpeed; vy <= maxSpeed; vy++ {
+				for vx := -maxSpeed; vx <= maxSpeed; vx++ {
+					current := &Cell{X: x, Y: y, Available: true, Speed: Velocity{X: vx, Y: vy}}
+
+					// there are no hops after the race is finished
+					if rules.Finishes(current, finish) {
+						continue
+					}
+
+					e := estimate(h, current, finish, rules)
+
+					for _, neighbor := range grid.GetNeighbors(current, rules) {
+						if next := estimate(h, neighbor, finish, rules); e > next+1 {
+							return errors.Errorf("estimate %d of state %v exceeds estimate %d of state %v by more than one hop",
+								e, current.State(), next, neighbor.State())
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package pathfinder
 import (
 	"testing"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -46,8 +47,134 @@ func TestChebyshevDistance(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			got := ChebyshevDistance(test.a, test.b)
+			got := ChebyshevDistance(test.a, test.b, Rules{})
 			assert.Equal(t, test.want, got)
 		})
 	}
 }
+
+func TestVelocityDistance(t *testing.T) {
+	tests := []struct {
+		name  string
+		a     *Cell
+		b     *Cell
+		rules Rules
+		want  int
+	}{
+		{
+			name: "nil cells",
+			a:    nil,
+			b:    nil,
+			want: 0,
+		},
+		{
+			name:  "custom speed and acceleration",
+			a:     &Cell{X: 0, Y: 0, Speed: Velocity{X: 1, Y: 0}},
+			b:     &Cell{X: 12, Y: 0},
+			rules: Rules{MaxSpeed: 5, MaxAcceleration: 2},
+			want:  3,
+		},
+		{
+			name: "same cell",
+			a:    &Cell{X: 1, Y: 2},
+			b:    &Cell{X: 1, Y: 2},
+			want: 0,
+		},
+		{
+			name: "accelerating from zero speed",
+			a:    &Cell{X: 0, Y: 0},
+			b:    &Cell{X: 9, Y: 2},
+			want: 4,
+		},
+		{
+			name: "moving at maximal speed",
+			a:    &Cell{X: 0, Y: 0, Speed: Velocity{X: 3, Y: 0}},
+			b:    &Cell{X: 9, Y: 0},
+			want: 3,
+		},
+		{
+			name: "moving away from the cell",
+			a:    &Cell{X: 5, Y: 0, Speed: Velocity{X: 2, Y: 0}},
+			b:    &Cell{X: 4, Y: 0},
+			want: 4,
+		},
+		{
+			name: "negative direction",
+			a:    &Cell{X: 0, Y: 6, Speed: Velocity{X: 0, Y: -2}},
+			b:    &Cell{X: 0, Y: 0},
+			want: 2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := VelocityDistance(test.a, test.b, test.rules)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestVerifyHeuristic(t *testing.T) {
+	tests := []struct {
+		name   string
+		grid   *Grid
+		h      Heuristic
+		finish *Cell
+		rules  Rules
+		err    error
+	}{
+		{
+			name:   "velocity distance is admissible",
+			grid:   NewGrid(8, 12, Obstacle{X1: 3, X2: 5, Y1: 2, Y2: 6}),
+			h:      VelocityDistance,
+			finish: &Cell{X: 10, Y: 4},
+			err:    nil,
+		},
+		{
+			name:   "velocity distance is admissible for custom rules",
+			grid:   NewGrid(8, 12, Obstacle{X1: 3, X2: 5, Y1: 2, Y2: 6}),
+			h:      VelocityDistance,
+			finish: &Cell{X: 10, Y: 4},
+			rules:  Rules{MaxSpeed: 5, MaxAcceleration: 2, AxisAcceleration: true, FlightCollision: true},
+			err:    nil,
+		},
+		{
+			name:   "chebyshev distance overestimates long hops",
+			grid:   NewGrid(8, 12, Obstacle{X1: 3, X2: 5, Y1: 2, Y2: 6}),
+			h:      ChebyshevDistance,
+			finish: &Cell{X: 10, Y: 4},
+			err:    errors.New("by more than one hop"),
+		},
+		{
+			name:   "constant overestimate",
+			grid:   NewGrid(3, 3),
+			h:      func(a, b *Cell, _ Rules) int { return 5 },
+			finish: &Cell{X: 2, Y: 2},
+			err:    errors.New("by more than one hop"),
+		},
+		{
+			name:   "finish cell out of grid",
+			grid:   NewGrid(3, 3),
+			h:      VelocityDistance,
+			finish: &Cell{X: 3, Y: 3},
+			err:    errors.New("finish cell is out of grid"),
+		},
+		{
+			name:   "nil heuristic",
+			grid:   NewGrid(3, 3),
+			h:      nil,
+			finish: &Cell{X: 2, Y: 2},
+			err:    errors.New("must be provided"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := VerifyHeuristic(test.grid, test.h, NewLandingGoal(test.finish), test.rules)
+
+			if test.err != nil {
+				assert.ErrorContains(t, err, test.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package pathfinder
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// Hint is the next hop of an optimal race from a state of the hopper in the middle of a race.
+type Hint struct {
+	// Acceleration is the acceleration of the next hop;
+	// it is zero if the race is finished at the state already.
+	Acceleration Acceleration
+	// Hops is the number of hops left to finish the race, including the next one.
+	Hops int
+	// Cost is the cost of the hops left, which is the number of them unless the grid has terrain.
+	Cost int
+}
+
+// Hint returns the next hop of the cheapest race to the finish from the state of the hopper
+// landed on the given cell with the given velocity (e.g., to help a player stuck halfway through the track).
+//
+// Unlike FindPath, which starts the races with the hopper resting on the start cells, the race is continued
+// with the given velocity, so the velocity of the cell is ignored. On a grid with timed obstacles,
+// the race is continued at the time of the cell.
+//
+// It returns a nil hint and no error if the state is a dead end, i.e., the race cannot be finished from it.
+// An error is returned if the state is out of the grid or cannot be reached by the hopper
+// (i.e., the hopper moving has landed on an unavailable cell, or the velocity exceeds the maximal speed),
+// or if the finish is not valid. The search may be limited by the search options just like FindPath.
+func (pf *GridPathfinder) Hint(ctx context.Context, cell *Cell, speed Velocity, finish Goal, opts ...SearchOption) (*Hint, error) {
+	if cell == nil || finish == nil || len(finish.Cells()) == 0 {
+		return nil, errors.New("cell and finish cells must be provided")
+	}
+
+	current := pf.Grid.GetCell(cell.X, cell.Y)
+	if current == nil {
+		return nil, errors.New("cell is out of grid")
+	}
+
+	maxSpeed := pf.Rules.GetMaxSpeed()
+	if speed.X < -maxSpeed || speed.X > maxSpeed || speed.Y < -maxSpeed || speed.Y > maxSpeed {
+		return nil, errors.Errorf("velocity %v exceeds max speed %d", speed, maxSpeed)
+	}
+
+	current.Speed = speed
+	if pf.Grid.Timed() {
+		current.Time = pf.Grid.timeline.clock(cell.Time)
+	}
+
+	// the hopper resting may stand anywhere, while the one moving has landed on an available cell
+	if speed != (Velocity{}) && !pf.Grid.IsAvailableAt(current.X, current.Y, current.Time) {
+		return nil, errors.New("cell is not available")
+	}
+
+	if err := checkFinish(pf.Grid, finish); err != nil {
+		return nil, err
+	}
+
+	s, err := pf.search(ctx, []*Cell{current}, finish, opts...)
+	if err != nil || s == nil {
+		return nil, err
+	}
+
+	h := &Hint{
+		Hops: len(s.Hops),
+		Cost: s.Cost,
+	}
+	if len(s.Hops) > 0 {
+		h.Acceleration = s.Hops[0].Acceleration
+	}
+
+	return h, nil
+}
@@ -0,0 +1,199 @@
+package pathfinder
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGridPathfinder_Hint(t *testing.T) {
+	tests := []struct {
+		name   string
+		grid   *Grid
+		cell   *Cell
+		speed  Velocity
+		finish Goal
+		want   *Hint
+		err    error
+	}{
+		{
+			name:   "resting on the start",
+			grid:   NewGrid(1, 10),
+			cell:   &Cell{X: 0, Y: 0},
+			finish: NewLandingGoal(&Cell{X: 9, Y: 0}),
+			want:   &Hint{Acceleration: Acceleration{X: 1}, Hops: 4, Cost: 4},
+		},
+		{
+			name:   "going at full speed",
+			grid:   NewGrid(1, 10),
+			cell:   &Cell{X: 3, Y: 0},
+			speed:  Velocity{X: 3},
+			finish: NewLandingGoal(&Cell{X: 9, Y: 0}),
+			want:   &Hint{Acceleration: Acceleration{X: 0}, Hops: 2, Cost: 2},
+		},
+		{
+			name:   "turning back",
+			grid:   NewGrid(3, 10),
+			cell:   &Cell{X: 5, Y: 1},
+			speed:  Velocity{X: -1},
+			finish: NewLandingGoal(&Cell{X: 9, Y: 1}),
+			want:   &Hint{Acceleration: Acceleration{X: 1, Y: -1}, Hops: 4, Cost: 4},
+		},
+		{
+			name:   "too fast to finish",
+			grid:   NewGrid(1, 10),
+			cell:   &Cell{X: 8, Y: 0},
+			speed:  Velocity{X: 3},
+			finish: NewLandingGoal(&Cell{X: 9, Y: 0}),
+		},
+		{
+			name:   "finished",
+			grid:   NewGrid(1, 10),
+			cell:   &Cell{X: 9, Y: 0},
+			speed:  Velocity{X: 2},
+			finish: NewLandingGoal(&Cell{X: 9, Y: 0}),
+			want:   &Hint{},
+		},
+		{
+			name:   "velocity of the cell ignored",
+			grid:   NewGrid(1, 10),
+			cell:   &Cell{X: 3, Y: 0, Speed: Velocity{X: 1}},
+			speed:  Velocity{X: 3},
+			finish: NewLandingGoal(&Cell{X: 9, Y: 0}),
+			want:   &Hint{Acceleration: Acceleration{X: 0}, Hops: 2, Cost: 2},
+		},
+		{
+			name:   "terrain",
+			grid:   NewGrid(1, 10).WithTerrain(Terrain{X1: 6, X2: 6, Y1: 0, Y2: 0, Cost: 5}),
+			cell:   &Cell{X: 3, Y: 0},
+			speed:  Velocity{X: 3},
+			finish: NewLandingGoal(&Cell{X: 9, Y: 0}),
+			want:   &Hint{Acceleration: Acceleration{X: -1}, Hops: 3, Cost: 3},
+		},
+		{
+			name:   "no cell",
+			grid:   NewGrid(1, 10),
+			finish: NewLandingGoal(&Cell{X: 9, Y: 0}),
+			err:    errors.New("cell and finish cells must be provided"),
+		},
+		{
+			name:   "cell out of grid",
+			grid:   NewGrid(1, 10),
+			cell:   &Cell{X: 10, Y: 0},
+			finish: NewLandingGoal(&Cell{X: 9, Y: 0}),
+			err:    errors.New("cell is out of grid"),
+		},
+		{
+			name:   "velocity out of range",
+			grid:   NewGrid(1, 10),
+			cell:   &Cell{X: 3, Y: 0},
+			speed:  Velocity{X: 4},
+			finish: NewLandingGoal(&Cell{X: 9, Y: 0}),
+			err:    errors.New("velocity (4,0) exceeds max speed 3"),
+		},
+		{
+			name:   "landed on an obstacle",
+			grid:   NewGrid(1, 10, Obstacle{X1: 3, X2: 3, Y1: 0, Y2: 0}),
+			cell:   &Cell{X: 3, Y: 0},
+			speed:  Velocity{X: 2},
+			finish: NewLandingGoal(&Cell{X: 9, Y: 0}),
+			err:    errors.New("cell is not available"),
+		},
+		{
+			name:   "finish cell not available",
+			grid:   NewGrid(1, 10, Obstacle{X1: 9, X2: 9, Y1: 0, Y2: 0}),
+			cell:   &Cell{X: 3, Y: 0},
+			finish: NewLandingGoal(&Cell{X: 9, Y: 0}),
+			err:    errors.New("finish cell is not available"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pf := &GridPathfinder{Grid: test.grid, Heuristic: VelocityDistance}
+			got, err := pf.Hint(context.Background(), test.cell, test.speed, test.finish)
+
+			if test.err != nil {
+				assert.ErrorContains(t, err, test.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+// TestGridPathfinder_Hint_Reference checks the hints from random states of random tracks
+// against the policies of the tracks.
+func TestGridPathfinder_Hint_Reference(t *testing.T) {
+	rules := map[string]Rules{
+		"classic rules":    {},
+		"fast hoppers":     {MaxSpeed: 5, MaxAcceleration: 2},
+		"stop at finish":   {StopAtFinish: true},
+		"flight collision": {FlightCollision: true},
+	}
+
+	for rName, r := range rules {
+		t.Run(rName, func(t *testing.T) {
+			rnd := rand.New(rand.NewSource(1))
+
+			for i := 0; i < 100; i++ {
+				grid, _, finish := randomTrack(rnd, 8, 8)
+				goal := NewLandingGoal(finish...)
+
+				policy, err := NewPolicy(grid, goal, r)
+				require.NoError(t, err)
+
+				pf := &GridPathfinder{Grid: grid, Heuristic: VelocityDistance, Rules: r}
+				maxSpeed := r.GetMaxSpeed()
+
+				for j := 0; j < 20; j++ {
+					cell := randomCells(rnd, grid, 1)
+					if len(cell) == 0 {
+						continue
+					}
+					speed := Velocity{X: rnd.Intn(2*maxSpeed+1) - maxSpeed, Y: rnd.Intn(2*maxSpeed+1) - maxSpeed}
+
+					got, err := pf.Hint(context.Background(), cell[0], speed, goal)
+					require.NoError(t, err)
+
+					_, hops, ok := policy.Lookup(cell[0].X, cell[0].Y, speed)
+					assert.Equal(t, ok, got != nil, "state %v %v, finish %v", cell[0], speed, finish)
+					if got == nil || !ok {
+						continue
+					}
+					assert.Equal(t, hops, got.Hops, "state %v %v, finish %v", cell[0], speed, finish)
+
+					// the hint leads to a state the policy finishes the race from with a hop less
+					if got.Hops > 0 {
+						next := Velocity{X: speed.X + got.Acceleration.X, Y: speed.Y + got.Acceleration.Y}
+						_, nextHops, ok := policy.Lookup(cell[0].X+next.X, cell[0].Y+next.Y, next)
+						assert.True(t, ok)
+						assert.Equal(t, hops-1, nextHops)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGridPathfinder_Hint_Timed(t *testing.T) {
+	// the finish is open at odd times only
+	grid := NewGrid(1, 7, Obstacle{X1: 3, X2: 3, Y1: 0, Y2: 0, ActiveFor: 1, Period: 2})
+	pf := &GridPathfinder{Grid: grid, Heuristic: VelocityDistance}
+	finish := NewLandingGoal(&Cell{X: 3, Y: 0})
+
+	// the finish is open for the next hop at the odd time
+	got, err := pf.Hint(context.Background(), &Cell{X: 2, Y: 0, Time: 2}, Velocity{X: 1}, finish)
+	assert.NoError(t, err)
+	assert.Equal(t, &Hint{Acceleration: Acceleration{X: 0}, Hops: 1, Cost: 1}, got)
+
+	// the finish is closed for the next hop at the even time, and the hopper cannot turn back on a single row
+	got, err = pf.Hint(context.Background(), &Cell{X: 2, Y: 0, Time: 3}, Velocity{X: 1}, finish)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+}
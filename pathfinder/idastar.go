@@ -0,0 +1,306 @@
+package pathfinder
+
+import (
+	"context"
+	"sort"
+)
+
+// DefaultTableSize is the default number of entries of the transposition table of IterativeDeepeningPathfinder.
+// The table of the default size takes about 3.5 MiB.
+const DefaultTableSize = 1 << 16
+
+// IterativeDeepeningPathfinder finds the shortest path between two cells in a given grid
+// using the iterative deepening A* algorithm (IDA*).
+//
+// Unlike GridPathfinder, it keeps no queue of the states to explore: each iteration is a depth-first search
+// limited by the estimated cost of the race, so the memory it takes is fixed by the size of its transposition table
+// and the length of the race followed. It trades the memory for time, as the states are explored again
+// by each iteration, so it suits the tracks whose state space does not fit into the memory.
+//
+// The pathfinder keeps no state between searches, so it can be used
+// for any number of FindPath calls, including concurrent ones.
+type IterativeDeepeningPathfinder struct {
+	Grid      *Grid
+	Heuristic Heuristic
+	Rules     Rules
+
+	// TableSize is the number of entries of the transposition table of each search
+	// remembering the cheapest races to the states explored by the current iteration.
+	// It is rounded up to a power of two; zero or a negative value disables the table.
+	TableSize int
+}
+
+// NewIterativeDeepeningPathfinder returns a new IDA* pathfinder with the given grid, heuristic function and game rules
+// and the transposition table of the default size.
+func NewIterativeDeepeningPathfinder(grid *Grid, h Heuristic, rules Rules) Pathfinder {
+	if grid == nil || h == nil {
+		return nil
+	}
+
+	return &IterativeDeepeningPathfinder{
+		Grid:      grid,
+		Heuristic: h,
+		Rules:     rules,
+		TableSize: DefaultTableSize,
+	}
+}
+
+// FindPath returns the shortest path from any of the start cells to the finish.
+//
+// Each iteration follows the races from all the start cells depth first, cutting off the states
+// whose estimated race cost (the cost of the race to the state plus the heuristic estimate) exceeds the bound.
+// The first bound is the estimate of the best start cell, and each next one is the lowest estimate cut off
+// by the previous iteration, so the first race found is the optimal one if the heuristic is admissible.
+// The states met again within an iteration at no lower cost are skipped if the transposition table remembers them,
+// and a race never returns to its own states.
+//
+// If the finish cannot be reached, the search is over once an iteration cuts off no state, which may take
+// many iterations on a large track, so the search is better limited by the search options.
+// The statistics count the states expanded by all the iterations, and their peak of the open states
+// is the length of the longest race followed.
+//
+// The partial race of an aborted search leads to the state with the lowest heuristic estimate met so far.
+func (pf *IterativeDeepeningPathfinder) FindPath(ctx context.Context, starts []*Cell, finish Goal, opts ...SearchOption) (*Solution, error) {
+	initials, err := getEndpoints(pf.Grid, starts, finish)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := newSearchSettings(opts)
+
+	var stats SearchStats
+	defer settings.report(&stats)
+
+	s := &deepeningSearch{
+		ctx:      ctx,
+		pf:       pf,
+		finish:   finish,
+		settings: settings,
+		stats:    &stats,
+		table:    newTranspositionTable(pf.TableSize),
+		onRace:   make(map[State]bool),
+	}
+
+	// the start states are followed in turn, the most promising first
+	var roots []*Cell
+	var estimates []int
+	seen := make(map[State]bool, len(initials))
+	for _, initial := range initials {
+		if seen[initial.State()] {
+			continue
+		}
+		seen[initial.State()] = true
+
+		roots = append(roots, initial)
+		estimates = append(estimates, pf.estimate(initial, finish))
+		stats.Generated++
+	}
+	sortByEstimate(roots, estimates)
+
+	for bound := estimates[0]; ; bound = s.next {
+		s.iteration++
+		s.next = infiniteCost
+
+		for i, root := range roots {
+			found, err := s.follow(root, 0, estimates[i], bound)
+			if err != nil {
+				return nil, s.abort(err)
+			}
+			if found {
+				return newSolution(pf.Grid, s.race, s.at), nil
+			}
+		}
+
+		if s.next >= infiniteCost {
+			// No path found
+			return nil, nil
+		}
+	}
+}
+
+// estimate returns the heuristic estimate of the cost of the hops needed
+// to finish the race from the given cell.
+func (pf *IterativeDeepeningPathfinder) estimate(cell *Cell, finish Goal) int {
+	return estimate(pf.Heuristic, cell, finish, pf.Rules) * pf.Grid.MinLandingCost()
+}
+
+// deepeningSearch holds the state of a single IDA* search.
+type deepeningSearch struct {
+	ctx      context.Context
+	pf       *IterativeDeepeningPathfinder
+	finish   Goal
+	settings *searchSettings
+	stats    *SearchStats
+
+	// iteration is the number of the current iteration.
+	iteration int
+	// next is the lowest estimated race cost cut off by the current iteration.
+	next int
+
+	table *transpositionTable
+
+	// race holds the states of the race followed, and onRace holds them for the lookup.
+	race   []*Cell
+	onRace map[State]bool
+	// at is the position the race is finished at once it is found.
+	at Position
+
+	// partial holds the race to the state with the lowest heuristic estimate met so far.
+	partial         []*Cell
+	partialEstimate int
+}
+
+// follow follows the races from the given state reached at the given cost
+// (with the given heuristic estimate) until their estimated cost exceeds the bound.
+// It reports whether a race has been finished, leaving the race in s.race then.
+func (s *deepeningSearch) follow(cell *Cell, g, h, bound int) (bool, error) {
+	if f := g + h; f > bound {
+		s.next = min(s.next, f)
+		return false, nil
+	}
+
+	if s.partial == nil || h < s.partialEstimate {
+		s.partial = append(append(s.partial[:0], s.race...), cell)
+		s.partialEstimate = h
+	}
+
+	if at, ok := s.pf.Rules.FinishesAt(cell, s.finish); ok {
+		s.race = append(s.race, cell)
+		s.at = at
+		return true, nil
+	}
+
+	if s.table.seen(cell.State(), g, s.iteration) {
+		return false, nil
+	}
+
+	if err := s.settings.check(s.ctx, s.stats.Expanded, len(s.race)+1); err != nil {
+		return false, err
+	}
+	s.stats.Expanded++
+
+	s.race = append(s.race, cell)
+	s.onRace[cell.State()] = true
+	s.stats.open(len(s.race))
+
+	// the most promising hops are followed first
+	next := s.pf.Grid.GetNeighbors(cell, s.pf.Rules)
+	estimates := make([]int, len(next))
+	for i, c := range next {
+		estimates[i] = s.pf.estimate(c, s.finish)
+	}
+	sortByEstimate(next, estimates)
+
+	for i, c := range next {
+		if s.onRace[c.State()] {
+			continue
+		}
+		s.stats.Generated++
+
+		found, err := s.follow(c, g+s.pf.Grid.LandingCost(c.X, c.Y), estimates[i], bound)
+		if found || err != nil {
+			return found, err
+		}
+	}
+
+	s.race = s.race[:len(s.race)-1]
+	delete(s.onRace, cell.State())
+
+	return false, nil
+}
+
+// abort returns the error aborting the search
+// carrying the race to the state with the lowest heuristic estimate met so far.
+func (s *deepeningSearch) abort(reason error) error {
+	err := &AbortedError{Reason: reason}
+
+	if len(s.partial) > 0 {
+		last := s.partial[len(s.partial)-1]
+		err.Partial = newSolution(s.pf.Grid, s.partial, Position{X: last.X, Y: last.Y})
+	}
+
+	return err
+}
+
+// sortByEstimate sorts the cells by their estimates ascending keeping the order of the equally estimated ones.
+func sortByEstimate(cells []*Cell, estimates []int) {
+	sort.Stable(byEstimate{cells: cells, estimates: estimates})
+}
+
+// byEstimate implements sort.Interface to sort the cells by their estimates.
+type byEstimate struct {
+	cells     []*Cell
+	estimates []int
+}
+
+func (b byEstimate) Len() int           { return len(b.cells) }
+func (b byEstimate) Less(i, j int) bool { return b.estimates[i] < b.estimates[j] }
+func (b byEstimate) Swap(i, j int) {
+	b.cells[i], b.cells[j] = b.cells[j], b.cells[i]
+	b.estimates[i], b.estimates[j] = b.estimates[j], b.estimates[i]
+}
+
+// transpositionTable remembers the cheapest races to the states explored by an iteration of the IDA* search
+// in a fixed number of entries. The entries are addressed by the hash of the state, and a new state
+// takes the place of the old one with the same hash.
+type transpositionTable struct {
+	entries []tableEntry
+	mask    uint64
+}
+
+// tableEntry is an entry of the transposition table.
+type tableEntry struct {
+	state State
+	// g is the cost of the cheapest race to the state explored by the iteration.
+	g int
+	// iteration is the iteration the entry is made by; the entries of the previous iterations are void,
+	// so the table is never cleared.
+	iteration int
+}
+
+// newTranspositionTable returns a new transposition table of at least the given number of entries,
+// or nil if the size is not positive.
+func newTranspositionTable(size int) *transpositionTable {
+	if size <= 0 {
+		return nil
+	}
+
+	n := 1
+	for n < size {
+		n <<= 1
+	}
+
+	return &transpositionTable{
+		entries: make([]tableEntry, n),
+		mask:    uint64(n - 1),
+	}
+}
+
+// seen reports whether the state has been explored by the given iteration at no higher cost,
+// in which case following the races from it again finds nothing new.
+// Otherwise, the state is remembered to be explored at the given cost.
+func (t *transpositionTable) seen(state State, g, iteration int) bool {
+	if t == nil {
+		return false
+	}
+
+	e := &t.entries[hashState(state)&t.mask]
+	if e.iteration == iteration && e.state == state && e.g <= g {
+		return true
+	}
+
+	*e = tableEntry{state: state, g: g, iteration: iteration}
+
+	return false
+}
+
+// hashState returns the hash of the state.
+func hashState(s State) uint64 {
+	h := uint64(14695981039346656037)
+	for _, v := range [...]int{s.X, s.Y, s.Speed.X, s.Speed.Y, s.Time} {
+		h ^= uint64(v)
+		h *= 1099511628211
+	}
+
+	return h ^ h>>32
+}
@@ -0,0 +1,195 @@
+package pathfinder
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewIterativeDeepeningPathfinder(t *testing.T) {
+	grid := NewGrid(3, 3)
+
+	got := NewIterativeDeepeningPathfinder(grid, VelocityDistance, Rules{})
+	assert.Equal(t, grid, got.(*IterativeDeepeningPathfinder).Grid)
+	assert.Equal(t, DefaultTableSize, got.(*IterativeDeepeningPathfinder).TableSize)
+
+	assert.Nil(t, NewIterativeDeepeningPathfinder(nil, VelocityDistance, Rules{}))
+	assert.Nil(t, NewIterativeDeepeningPathfinder(grid, nil, Rules{}))
+}
+
+func TestIterativeDeepeningPathfinder_FindPath(t *testing.T) {
+	tests := []struct {
+		name   string
+		grid   *Grid
+		start  *Cell
+		finish *Cell
+		want   *Solution
+		err    error
+	}{
+		{
+			name:   "valid path found",
+			grid:   NewGrid(3, 3),
+			start:  &Cell{X: 0, Y: 0},
+			finish: &Cell{X: 2, Y: 2},
+			want: &Solution{
+				Start: State{X: 0, Y: 0},
+				Hops: []Hop{
+					{X: 1, Y: 1, Speed: Velocity{X: 1, Y: 1}, Acceleration: Acceleration{X: 1, Y: 1}},
+					{X: 2, Y: 2, Speed: Velocity{X: 1, Y: 1}, Acceleration: Acceleration{X: 0, Y: 0}},
+				},
+				Finish: Position{X: 2, Y: 2},
+				Cost:   2,
+			},
+		},
+		{
+			name:   "start is the finish",
+			grid:   NewGrid(3, 3),
+			start:  &Cell{X: 1, Y: 1},
+			finish: &Cell{X: 1, Y: 1},
+			want: &Solution{
+				Start:  State{X: 1, Y: 1},
+				Hops:   []Hop{},
+				Finish: Position{X: 1, Y: 1},
+			},
+		},
+		{
+			name: "no path found",
+			grid: NewGrid(3, 3, []Obstacle{
+				{X1: 1, Y1: 0, X2: 1, Y2: 2},
+				{X1: 0, Y1: 1, X2: 2, Y2: 1},
+			}...),
+			start:  &Cell{X: 0, Y: 0},
+			finish: &Cell{X: 2, Y: 2},
+		},
+		{
+			name:   "nil input cell",
+			grid:   NewGrid(3, 3),
+			start:  &Cell{X: 0, Y: 0},
+			finish: nil,
+			err:    errors.New("start and finish cells must be provided"),
+		},
+		{
+			name:   "finish cell not available",
+			grid:   NewGrid(3, 3, Obstacle{X1: 2, Y1: 2, X2: 2, Y2: 2}),
+			start:  &Cell{X: 0, Y: 0},
+			finish: &Cell{X: 2, Y: 2},
+			err:    errors.New("finish cell is not available"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pf := NewIterativeDeepeningPathfinder(test.grid, VelocityDistance, Rules{})
+			got, err := pf.FindPath(context.Background(), []*Cell{test.start}, NewLandingGoal(test.finish))
+
+			if test.err != nil {
+				assert.ErrorContains(t, err, test.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+// TestIterativeDeepeningPathfinder_FindPath_Reference checks that the IDA* algorithm
+// finds races of the same cost as the A* algorithm on random tracks with terrain and timed obstacles,
+// whatever the size of its transposition table is.
+func TestIterativeDeepeningPathfinder_FindPath_Reference(t *testing.T) {
+	rules := map[string]Rules{
+		"classic rules":    {},
+		"fast hoppers":     {MaxSpeed: 5, MaxAcceleration: 2},
+		"stop at finish":   {StopAtFinish: true},
+		"flight collision": {FlightCollision: true},
+	}
+	tables := map[string]int{
+		"default table": DefaultTableSize,
+		"small table":   1024,
+	}
+
+	for rName, r := range rules {
+		for tName, size := range tables {
+			t.Run(rName+", "+tName, func(t *testing.T) {
+				rnd := rand.New(rand.NewSource(1))
+
+				for i := 0; i < 200; i++ {
+					grid, starts, finish := randomTrack(rnd, 8, 8)
+					goal := NewLandingGoal(finish...)
+
+					var terrain []Terrain
+					for n := rnd.Intn(3); n > 0; n-- {
+						x, y := rnd.Intn(grid.Cols), rnd.Intn(grid.Rows)
+						terrain = append(terrain, Terrain{X1: x, X2: x + rnd.Intn(4), Y1: y, Y2: y + rnd.Intn(4), Cost: rnd.Intn(6) - 1})
+					}
+					grid = grid.WithTerrain(terrain...)
+
+					want, err := NewGridPathfinder(grid, VelocityDistance, r).FindPath(context.Background(), starts, goal)
+					assert.NoError(t, err)
+					if want == nil && size < DefaultTableSize {
+						// proving the finish unreachable without the table may take too long
+						continue
+					}
+
+					pf := &IterativeDeepeningPathfinder{Grid: grid, Heuristic: VelocityDistance, Rules: r, TableSize: size}
+					got, err := pf.FindPath(context.Background(), starts, goal)
+					assert.NoError(t, err)
+
+					assert.Equal(t, want == nil, got == nil, "starts %v, finish %v, terrain %v", starts, finish, terrain)
+					if got != nil && want != nil {
+						assert.Equal(t, want.Cost, got.Cost, "starts %v, finish %v, terrain %v", starts, finish, terrain)
+						assert.True(t, goal.Contains(got.Finish.X, got.Finish.Y))
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestIterativeDeepeningPathfinder_FindPath_Timed(t *testing.T) {
+	// the gate is open at odd times only
+	grid := NewGrid(1, 7, Obstacle{X1: 3, X2: 3, Y1: 0, Y2: 0, ActiveFor: 1, Period: 2})
+
+	got, err := NewIterativeDeepeningPathfinder(grid, VelocityDistance, Rules{}).
+		FindPath(context.Background(), []*Cell{{X: 0, Y: 0}}, NewLandingGoal(&Cell{X: 6, Y: 0}))
+	assert.NoError(t, err)
+	assert.Equal(t, "(0,0) (1,0) (2,0) (4,0) (6,0)", racePositions(got))
+}
+
+func TestIterativeDeepeningPathfinder_FindPath_Aborted(t *testing.T) {
+	grid := NewGrid(20, 20, Obstacle{X1: 0, X2: 17, Y1: 10, Y2: 10})
+
+	var stats SearchStats
+	got, err := NewIterativeDeepeningPathfinder(grid, VelocityDistance, Rules{FlightCollision: true}).FindPath(context.Background(),
+		[]*Cell{{X: 0, Y: 0}}, NewLandingGoal(&Cell{X: 0, Y: 19}), WithMaxExpansions(50), WithStats(&stats))
+	assert.Nil(t, got)
+	assert.ErrorIs(t, err, ErrMaxExpansions)
+	assert.Equal(t, 50, stats.Expanded)
+
+	var aborted *AbortedError
+	assert.True(t, errors.As(err, &aborted))
+	assert.NotNil(t, aborted.Partial)
+}
+
+func TestTranspositionTable_seen(t *testing.T) {
+	table := newTranspositionTable(5)
+	assert.Len(t, table.entries, 8)
+
+	s := State{X: 1, Y: 2, Speed: Velocity{X: 1}}
+	assert.False(t, table.seen(s, 3, 1))
+	assert.True(t, table.seen(s, 3, 1))
+	assert.True(t, table.seen(s, 4, 1))
+	// a cheaper race is followed again
+	assert.False(t, table.seen(s, 2, 1))
+	assert.True(t, table.seen(s, 2, 1))
+	// the entries of the previous iterations are void
+	assert.False(t, table.seen(s, 2, 2))
+
+	// no table remembers nothing
+	var none *transpositionTable
+	assert.Nil(t, newTranspositionTable(0))
+	assert.False(t, none.seen(s, 3, 1))
+	assert.False(t, none.seen(s, 3, 1))
+}
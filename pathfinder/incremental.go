@@ -0,0 +1,491 @@
+package pathfinder
+
+import (
+	"container/heap"
+	"context"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// infiniteCost is the cost of the states no race is known to reach.
+// It is far below the largest integer, so the estimates can be added to it.
+const infiniteCost = math.MaxInt32
+
+// IncrementalPlanner finds the optimal race on a track changing between the searches
+// (e.g., in a track editor) reusing the work done by the previous searches.
+//
+// The planner implements Lifelong Planning A*, the incremental search D* Lite is built upon
+// (the start of the race never moves, so the search runs forward from the start cells).
+// Each state keeps the cost of the best race to it found so far (g) and the cost of the best race
+// through its predecessors (rhs), and only the states where they differ are put into the queue.
+// Once the track changes, only the states around the changed cells are re-evaluated,
+// and the search repairs the costs spreading from them instead of starting from scratch.
+//
+// The planner changes its own copy of the grid, so the grid it is created with is never modified.
+// The planner is not safe for concurrent use.
+type IncrementalPlanner struct {
+	grid      *Grid
+	heuristic Heuristic
+	rules     Rules
+	finish    Goal
+
+	// nodes holds the bookkeeping of every state discovered so far
+	nodes map[State]*lpaNode
+	// byCell holds the discovered states by the cells they are at,
+	// so the states around the changed cells are found without scanning all of them
+	byCell map[Position][]*lpaNode
+	// finishers holds the discovered states finishing the race
+	finishers map[State]*lpaNode
+	// goal is the virtual state every finishing state leads to for free
+	goal *lpaNode
+	// coverage holds the number of the obstacles covering each cell of the grid laid out row by row,
+	// so a cell becomes available once no obstacle covers it
+	coverage []int32
+
+	open *priorityQueue
+}
+
+// lpaNode holds the bookkeeping of a state for the incremental search.
+//
+// The queue orders the nodes by fCost and hCost, so they hold the key of the state (see setKey).
+type lpaNode struct {
+	*node
+
+	// g is the cost of the best race to the state found so far.
+	g int
+	// rhs is the cost of the best race to the state through its predecessors.
+	rhs int
+	// h is the heuristic estimate of the cost of the hops needed to finish the race from the state.
+	h int
+
+	// start indicates whether the state is a start state.
+	start bool
+	// finishes indicates whether the state finishes the race at position at.
+	finishes bool
+	at       Position
+}
+
+// NewIncrementalPlanner returns a new incremental planner of the race from any of the start cells to the finish
+// on a copy of the given grid with the given heuristic function and game rules.
+//
+// The heuristic function must be consistent (e.g., VelocityDistance).
+// The planner supports dense grids without timed obstacles only, and every landing must cost something:
+// the states landing for free may keep the costs of each other once the race to them is blocked.
+func NewIncrementalPlanner(grid *Grid, h Heuristic, rules Rules, starts []*Cell, finish Goal) (*IncrementalPlanner, error) {
+	if grid == nil || h == nil {
+		return nil, errors.New("grid and heuristic must be provided")
+	}
+	if grid.obstacles != nil {
+		return nil, errors.New("incremental planning is not supported on sparse grids")
+	}
+	if grid.Timed() {
+		return nil, errors.New("incremental planning is not supported on grids with timed obstacles")
+	}
+	if grid.MinLandingCost() <= 0 {
+		return nil, errors.New("incremental planning is not supported on grids with free landings")
+	}
+
+	initials, err := getEndpoints(grid, starts, finish)
+	if err != nil {
+		return nil, err
+	}
+
+	// the planner owns its copy of the track
+	own := *grid
+	own.occupied = append([]uint64{}, grid.occupied...)
+
+	p := &IncrementalPlanner{
+		grid:      &own,
+		heuristic: h,
+		rules:     rules,
+		finish:    finish,
+		nodes:     make(map[State]*lpaNode),
+		byCell:    make(map[Position][]*lpaNode),
+		finishers: make(map[State]*lpaNode),
+		goal:      &lpaNode{node: &node{}, g: infiniteCost, rhs: infiniteCost},
+		open:      &priorityQueue{},
+	}
+	heap.Init(p.open)
+	setKey(p.goal)
+
+	// the obstacles of the grid cover their cells once
+	p.coverage = make([]int32, grid.Rows*grid.Cols)
+	for i := range p.coverage {
+		if grid.isOccupied(i%grid.Cols, i/grid.Cols) {
+			p.coverage[i] = 1
+		}
+	}
+
+	for _, initial := range initials {
+		if _, ok := p.nodes[initial.State()]; ok {
+			continue
+		}
+
+		n := p.discover(initial)
+		n.start = true
+		p.updateState(n)
+	}
+
+	return p, nil
+}
+
+// Grid returns the current grid of the planner.
+//
+// The grid is changed by the planner, so it must not be searched while the obstacles change.
+func (p *IncrementalPlanner) Grid() *Grid {
+	return p.grid
+}
+
+// AddObstacle places the obstacle on the track.
+// The parts of the obstacle out of the grid are ignored.
+//
+// An error is returned if the obstacle is timed, as the planner does not support timed obstacles.
+func (p *IncrementalPlanner) AddObstacle(o Obstacle) error {
+	if o.timed() {
+		return errors.New("incremental planning does not support timed obstacles")
+	}
+
+	p.update(p.cover(o, 1))
+
+	return nil
+}
+
+// RemoveObstacle removes the obstacle placed on the track before.
+// The cells of the obstacle covered by other obstacles stay occupied, and the parts of the obstacle
+// out of the grid are ignored. The obstacles of the grid the planner is created with are indistinguishable,
+// so removing any of them clears the cells covered by the others as well.
+//
+// An error is returned if the obstacle is timed, as the planner does not support timed obstacles.
+func (p *IncrementalPlanner) RemoveObstacle(o Obstacle) error {
+	if o.timed() {
+		return errors.New("incremental planning does not support timed obstacles")
+	}
+
+	p.update(p.cover(o, -1))
+
+	return nil
+}
+
+// FindPath returns the optimal race on the current track, repairing the race found by the previous call.
+//
+// It returns a nil solution and no error if the finish cannot be reached from any start cell
+// (or all the finish cells are covered).
+// The search may be limited by the search options just like Pathfinder.FindPath; the statistics count the work
+// of this call only. An aborted search carries no partial race, but the work done is kept,
+// so the next call resumes the search.
+func (p *IncrementalPlanner) FindPath(ctx context.Context, opts ...SearchOption) (*Solution, error) {
+	// the finish must be available, just like on the start of FindPath of the pathfinders
+	available := false
+	for _, c := range p.finish.Cells() {
+		available = available || p.grid.IsAvailable(c.X, c.Y)
+	}
+	if !available {
+		return nil, nil
+	}
+
+	settings := newSearchSettings(opts)
+
+	var stats SearchStats
+	defer settings.report(&stats)
+
+	for p.open.Len() > 0 {
+		// the search is over once the goal is settled and no state as promising as the goal
+		// may make it more expensive
+		top := (*p.open)[0].node
+		if p.goal.g == p.goal.rhs &&
+			(top.fCost > p.goal.fCost || top.fCost == p.goal.fCost && top.hCost != underconsistent) {
+			break
+		}
+
+		if err := settings.check(ctx, stats.Expanded, p.open.Len()); err != nil {
+			return nil, &AbortedError{Reason: err}
+		}
+
+		heap.Pop(p.open)
+		if top == p.goal.node {
+			p.expandGoal()
+			continue
+		}
+		stats.Expanded++
+
+		u := p.nodes[top.state()]
+		if u.g > u.rhs {
+			// the state gets cheaper
+			u.g = u.rhs
+		} else {
+			// the state gets more expensive: it is re-evaluated together with its successors
+			u.g = infiniteCost
+			p.updateState(u)
+			stats.Reopened++
+		}
+
+		for _, s := range p.successors(u, &stats) {
+			p.updateState(s)
+		}
+		if u.finishes {
+			p.updateGoal()
+		}
+		stats.open(p.open.Len())
+	}
+
+	if p.goal.g >= infiniteCost {
+		// No path found
+		return nil, nil
+	}
+
+	return p.solution(), nil
+}
+
+// discover returns the bookkeeping of the state of the hopper standing on the given cell,
+// creating it if the state has not been discovered yet.
+func (p *IncrementalPlanner) discover(cell *Cell) *lpaNode {
+	if n, ok := p.nodes[cell.State()]; ok {
+		return n
+	}
+
+	n := &lpaNode{
+		node: newNode(cell, nil),
+		g:    infiniteCost,
+		rhs:  infiniteCost,
+		h:    estimate(p.heuristic, cell, p.finish, p.rules) * p.grid.MinLandingCost(),
+	}
+	n.at, n.finishes = p.rules.FinishesAt(cell, p.finish)
+
+	p.nodes[cell.State()] = n
+	at := Position{X: cell.X, Y: cell.Y}
+	p.byCell[at] = append(p.byCell[at], n)
+	if n.finishes {
+		p.finishers[cell.State()] = n
+	}
+
+	return n
+}
+
+// successors returns the states the hopper reaches with a single hop from the given state.
+// The race is over at the finishing states, so they have no successors.
+func (p *IncrementalPlanner) successors(u *lpaNode, stats *SearchStats) []*lpaNode {
+	if u.finishes {
+		return nil
+	}
+
+	next := p.grid.GetNeighbors(u.cell, p.rules)
+	successors := make([]*lpaNode, 0, len(next))
+	for _, c := range next {
+		if _, ok := p.nodes[c.State()]; !ok {
+			stats.Generated++
+		}
+		successors = append(successors, p.discover(c))
+	}
+
+	return successors
+}
+
+// updateState re-evaluates the cost of the race to the state through its predecessors
+// and puts the state into the queue if the cost differs from the best one found so far.
+func (p *IncrementalPlanner) updateState(u *lpaNode) {
+	u.rhs = p.predecessorsCost(u)
+
+	if i := p.open.GetIndex(u.node); i >= 0 {
+		heap.Remove(p.open, i)
+	}
+	if u.g != u.rhs {
+		setKey(u)
+		heap.Push(p.open, u.node)
+	}
+}
+
+// predecessorsCost returns the cost of the best race to the state through its predecessors.
+//
+// All the predecessors of a state stand on the same cell, as the hopper has landed with the same velocity,
+// so the hop from each of them is possible if the landing cell and the flight to it are clear.
+func (p *IncrementalPlanner) predecessorsCost(u *lpaNode) int {
+	if u.start {
+		// the hopper rests on the start cell before the race whatever covers it
+		return 0
+	}
+
+	x, y := u.cell.X, u.cell.Y
+	speed := u.cell.Speed
+	px, py := x-speed.X, y-speed.Y
+	if speed == (Velocity{}) || !p.grid.IsAvailable(x, y) ||
+		p.rules.FlightCollision && !clearFlight(px, py, x, y, p.grid.IsAvailable) {
+		return infiniteCost
+	}
+
+	maxSpeed := p.rules.GetMaxSpeed()
+	cost := p.grid.LandingCost(x, y)
+
+	best := infiniteCost
+	for _, a := range p.rules.Accelerations() {
+		v := Velocity{X: speed.X - a.X, Y: speed.Y - a.Y}
+		if v.X < -maxSpeed || v.X > maxSpeed || v.Y < -maxSpeed || v.Y > maxSpeed {
+			continue
+		}
+
+		pred, ok := p.nodes[State{X: px, Y: py, Speed: v}]
+		if !ok || pred.finishes || pred.g >= infiniteCost {
+			continue
+		}
+
+		best = min(best, pred.g+cost)
+	}
+
+	return best
+}
+
+// updateGoal re-evaluates the cost of the best race to the virtual goal state.
+func (p *IncrementalPlanner) updateGoal() {
+	p.goal.rhs = infiniteCost
+	for _, f := range p.finishers {
+		p.goal.rhs = min(p.goal.rhs, f.g)
+	}
+
+	if i := p.open.GetIndex(p.goal.node); i >= 0 {
+		heap.Remove(p.open, i)
+	}
+	setKey(p.goal)
+	if p.goal.g != p.goal.rhs {
+		heap.Push(p.open, p.goal.node)
+	}
+}
+
+// expandGoal expands the virtual goal state taken from the queue.
+func (p *IncrementalPlanner) expandGoal() {
+	if p.goal.g > p.goal.rhs {
+		p.goal.g = p.goal.rhs
+		return
+	}
+
+	p.goal.g = infiniteCost
+	p.updateGoal()
+}
+
+// update re-evaluates the states around the cells whose availability has changed:
+// the states on them, the states landing after a hop from them or over them,
+// and the states the hopper reaches from them.
+func (p *IncrementalPlanner) update(changed []Position) {
+	if len(changed) == 0 {
+		return
+	}
+
+	x1, y1, x2, y2 := changed[0].X, changed[0].Y, changed[0].X, changed[0].Y
+	for _, c := range changed {
+		x1, y1, x2, y2 = min(x1, c.X), min(y1, c.Y), max(x2, c.X), max(y2, c.Y)
+	}
+
+	// a single hop covers no more than the maximal speed along each axis
+	maxSpeed := p.rules.GetMaxSpeed()
+
+	// the states discovered by the update are not counted by the statistics of any search
+	var stats SearchStats
+	for y := y1 - maxSpeed; y <= y2+maxSpeed; y++ {
+		for x := x1 - maxSpeed; x <= x2+maxSpeed; x++ {
+			for _, u := range p.byCell[Position{X: x, Y: y}] {
+				p.updateState(u)
+
+				if u.g < infiniteCost {
+					for _, s := range p.successors(u, &stats) {
+						p.updateState(s)
+					}
+				}
+			}
+		}
+	}
+}
+
+// solution returns the optimal race to the cheapest finishing state
+// following the predecessors the race comes from back to a start state.
+//
+// The states of the optimal races are consistent once the search is over, so the race is followed
+// through the consistent predecessors the cost of the state is made of. The landing costs are positive,
+// so the cost decreases along the race followed back; the race backs off the dead ends it runs into.
+func (p *IncrementalPlanner) solution() *Solution {
+	var best *lpaNode
+	for _, f := range p.finishers {
+		if f.g == p.goal.g && f.g == f.rhs && (best == nil || isBetterPartial(f.node, best.node)) {
+			best = f
+		}
+	}
+
+	visited := map[*lpaNode]bool{best: true}
+	race := []*lpaNode{best}
+	for len(race) > 0 && !race[len(race)-1].start {
+		pred := p.predecessorOnRace(race[len(race)-1], visited)
+		if pred == nil {
+			// dead end: back off
+			race = race[:len(race)-1]
+			continue
+		}
+
+		visited[pred] = true
+		race = append(race, pred)
+	}
+
+	path := make([]*Cell, 0, len(race))
+	for i := len(race) - 1; i >= 0; i-- {
+		path = append(path, race[i].cell)
+	}
+
+	return newSolution(p.grid, path, best.at)
+}
+
+// predecessorOnRace returns a consistent predecessor of the state not visited yet
+// the cost of the state is made of, or nil if there is none.
+func (p *IncrementalPlanner) predecessorOnRace(u *lpaNode, visited map[*lpaNode]bool) *lpaNode {
+	speed := u.cell.Speed
+	px, py := u.cell.X-speed.X, u.cell.Y-speed.Y
+	cost := p.grid.LandingCost(u.cell.X, u.cell.Y)
+
+	for _, a := range p.rules.Accelerations() {
+		pred, ok := p.nodes[State{X: px, Y: py, Speed: Velocity{X: speed.X - a.X, Y: speed.Y - a.Y}}]
+		if ok && !visited[pred] && !pred.finishes && pred.g == pred.rhs && pred.g+cost == u.g {
+			return pred
+		}
+	}
+
+	return nil
+}
+
+// underconsistent is the tie-breaking key of the states whose cost has grown.
+const underconsistent = -infiniteCost
+
+// setKey sets the key of the state the queue orders the states by.
+//
+// The states are ordered by the estimated cost of the race through them: min(g, rhs) + h.
+// The equally promising states whose cost has grown come first, so the races relying on them are re-evaluated
+// before the goal is settled; the rest come in the order of their costs descending,
+// so the search dives towards the finish just like the A* search does.
+func setKey(n *lpaNode) {
+	k := min(n.g, n.rhs)
+	n.fCost = k + n.h
+
+	n.hCost = -k
+	if n.g < n.rhs {
+		n.hCost = underconsistent
+	}
+}
+
+// cover adds the given number of obstacles covering the cells of the area of the obstacle
+// (or removes them, if the number is negative) and returns the positions of the cells
+// whose availability has changed. The parts of the area out of the grid are ignored.
+func (p *IncrementalPlanner) cover(o Obstacle, obstacles int32) []Position {
+	var changed []Position
+
+	for y := max(o.Y1, 0); y <= min(o.Y2, p.grid.Rows-1); y++ {
+		for x := max(o.X1, 0); x <= min(o.X2, p.grid.Cols-1); x++ {
+			i := p.grid.index(x, y)
+			was := p.coverage[i] > 0
+			p.coverage[i] = max(p.coverage[i]+obstacles, 0)
+
+			if (p.coverage[i] > 0) == was {
+				continue
+			}
+
+			p.grid.occupied[i/64] ^= 1 << (i % 64)
+			changed = append(changed, Position{X: x, Y: y})
+		}
+	}
+
+	return changed
+}
@@ -0,0 +1,363 @@
+package pathfinder
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewIncrementalPlanner(t *testing.T) {
+	start, finish := []*Cell{{X: 0, Y: 0}}, NewLandingGoal(&Cell{X: 3, Y: 0})
+
+	tests := []struct {
+		name    string
+		grid    *Grid
+		starts  []*Cell
+		wantErr bool
+	}{
+		{
+			name:   "dense grid",
+			grid:   NewGrid(1, 4),
+			starts: start,
+		},
+		{
+			name:    "no grid",
+			starts:  start,
+			wantErr: true,
+		},
+		{
+			name:    "sparse grid",
+			grid:    newGrid(1, 4, true),
+			starts:  start,
+			wantErr: true,
+		},
+		{
+			name:    "timed obstacles",
+			grid:    NewGrid(1, 4, Obstacle{X1: 1, X2: 1, Y1: 0, Y2: 0, ActiveFrom: 1}),
+			starts:  start,
+			wantErr: true,
+		},
+		{
+			name:    "free landings",
+			grid:    NewGrid(1, 4).WithTerrain(Terrain{X1: 1, X2: 2, Y1: 0, Y2: 0, Cost: -1}),
+			starts:  start,
+			wantErr: true,
+		},
+		{
+			name:    "start out of grid",
+			grid:    NewGrid(1, 4),
+			starts:  []*Cell{{X: 5, Y: 0}},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := NewIncrementalPlanner(test.grid, VelocityDistance, Rules{}, test.starts, finish)
+			if test.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, got)
+		})
+	}
+}
+
+func TestIncrementalPlanner_FindPath(t *testing.T) {
+	type change struct {
+		obstacle Obstacle
+		remove   bool
+		// want is the race after the change, or empty if the race cannot be finished
+		want string
+	}
+
+	tests := []struct {
+		name    string
+		grid    *Grid
+		start   *Cell
+		finish  *Cell
+		want    string
+		changes []change
+	}{
+		{
+			name:   "obstacle added and removed",
+			grid:   NewGrid(1, 7),
+			start:  &Cell{X: 0, Y: 0},
+			finish: &Cell{X: 6, Y: 0},
+			want:   "(0,0) (1,0) (3,0) (6,0)",
+			changes: []change{
+				{obstacle: Obstacle{X1: 3, X2: 3, Y1: 0, Y2: 0}, want: "(0,0) (1,0) (2,0) (4,0) (6,0)"},
+				{obstacle: Obstacle{X1: 3, X2: 3, Y1: 0, Y2: 0}, remove: true, want: "(0,0) (1,0) (3,0) (6,0)"},
+			},
+		},
+		{
+			name:   "track blocked and cleared",
+			grid:   NewGrid(3, 5),
+			start:  &Cell{X: 0, Y: 1},
+			finish: &Cell{X: 4, Y: 1},
+			want:   "(0,1) (1,0) (2,0) (4,1)",
+			changes: []change{
+				{obstacle: Obstacle{X1: 2, X2: 3, Y1: 0, Y2: 2}},
+				{obstacle: Obstacle{X1: 2, X2: 2, Y1: 0, Y2: 0}, remove: true, want: "(0,1) (1,0) (2,0) (4,1)"},
+			},
+		},
+		{
+			name:   "overlapping obstacles",
+			grid:   NewGrid(1, 7),
+			start:  &Cell{X: 0, Y: 0},
+			finish: &Cell{X: 6, Y: 0},
+			want:   "(0,0) (1,0) (3,0) (6,0)",
+			changes: []change{
+				{obstacle: Obstacle{X1: 3, X2: 3, Y1: 0, Y2: 0}, want: "(0,0) (1,0) (2,0) (4,0) (6,0)"},
+				{obstacle: Obstacle{X1: 2, X2: 3, Y1: 0, Y2: 0}},
+				// the cell covered by the first obstacle stays occupied
+				{obstacle: Obstacle{X1: 2, X2: 3, Y1: 0, Y2: 0}, remove: true, want: "(0,0) (1,0) (2,0) (4,0) (6,0)"},
+				{obstacle: Obstacle{X1: 3, X2: 3, Y1: 0, Y2: 0}, remove: true, want: "(0,0) (1,0) (3,0) (6,0)"},
+			},
+		},
+		{
+			name:   "obstacle of the grid covered",
+			grid:   NewGrid(1, 7, Obstacle{X1: 3, X2: 3, Y1: 0, Y2: 0}),
+			start:  &Cell{X: 0, Y: 0},
+			finish: &Cell{X: 6, Y: 0},
+			want:   "(0,0) (1,0) (2,0) (4,0) (6,0)",
+			changes: []change{
+				{obstacle: Obstacle{X1: 3, X2: 4, Y1: 0, Y2: 0}},
+				// the obstacle of the grid stays in place
+				{obstacle: Obstacle{X1: 3, X2: 4, Y1: 0, Y2: 0}, remove: true, want: "(0,0) (1,0) (2,0) (4,0) (6,0)"},
+			},
+		},
+		{
+			name:   "obstacle out of grid",
+			grid:   NewGrid(1, 5),
+			start:  &Cell{X: 0, Y: 0},
+			finish: &Cell{X: 4, Y: 0},
+			want:   "(0,0) (1,0) (2,0) (4,0)",
+			changes: []change{
+				{obstacle: Obstacle{X1: 5, X2: 10, Y1: -3, Y2: 3}, want: "(0,0) (1,0) (2,0) (4,0)"},
+			},
+		},
+		{
+			name:   "finish blocked",
+			grid:   NewGrid(1, 5),
+			start:  &Cell{X: 0, Y: 0},
+			finish: &Cell{X: 4, Y: 0},
+			want:   "(0,0) (1,0) (2,0) (4,0)",
+			changes: []change{
+				{obstacle: Obstacle{X1: 4, X2: 4, Y1: 0, Y2: 0}},
+				{obstacle: Obstacle{X1: 4, X2: 4, Y1: 0, Y2: 0}, remove: true, want: "(0,0) (1,0) (2,0) (4,0)"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			before := gridMap(test.grid)
+
+			p, err := NewIncrementalPlanner(test.grid, VelocityDistance, Rules{}, []*Cell{test.start}, NewLandingGoal(test.finish))
+			require.NoError(t, err)
+
+			got, err := p.FindPath(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, racePositions(got))
+
+			for _, c := range test.changes {
+				if c.remove {
+					require.NoError(t, p.RemoveObstacle(c.obstacle))
+				} else {
+					require.NoError(t, p.AddObstacle(c.obstacle))
+				}
+
+				got, err = p.FindPath(context.Background())
+				assert.NoError(t, err)
+				if c.want == "" {
+					assert.Nil(t, got)
+					continue
+				}
+				assert.Equal(t, c.want, racePositions(got))
+			}
+
+			// the grid the planner is created with is not changed
+			assert.Equal(t, before, gridMap(test.grid))
+		})
+	}
+}
+
+// TestIncrementalPlanner_FindPathReference checks the costs of the repaired races
+// against the races found from scratch on random tracks changing randomly.
+func TestIncrementalPlanner_FindPathReference(t *testing.T) {
+	rules := map[string]Rules{
+		"classic rules":    {},
+		"fast hoppers":     {MaxSpeed: 5, MaxAcceleration: 2},
+		"flight collision": {FlightCollision: true},
+		"stop at finish":   {StopAtFinish: true},
+	}
+
+	for rName, r := range rules {
+		t.Run(rName, func(t *testing.T) {
+			rnd := rand.New(rand.NewSource(1))
+
+			for i := 0; i < 100; i++ {
+				grid, starts, finish := randomTrack(rnd, 8, 8)
+				goal := NewLandingGoal(finish...)
+
+				if rnd.Intn(2) == 0 {
+					x, y := rnd.Intn(grid.Cols), rnd.Intn(grid.Rows)
+					grid = grid.WithTerrain(Terrain{X1: x, X2: x + rnd.Intn(4), Y1: y, Y2: y + rnd.Intn(4), Cost: rnd.Intn(6) - 1})
+				}
+
+				p, err := NewIncrementalPlanner(grid, VelocityDistance, r, starts, goal)
+				if grid.MinLandingCost() == 0 {
+					// the grids with free landings are not supported
+					assert.Error(t, err)
+					continue
+				}
+				require.NoError(t, err)
+
+				var changes []Obstacle
+				for c := 0; c < 10; c++ {
+					x, y := rnd.Intn(grid.Cols), rnd.Intn(grid.Rows)
+					o := Obstacle{X1: x, X2: x + rnd.Intn(3), Y1: y, Y2: y + rnd.Intn(3)}
+					changes = append(changes, o)
+
+					if rnd.Intn(3) == 0 {
+						require.NoError(t, p.RemoveObstacle(o))
+					} else {
+						require.NoError(t, p.AddObstacle(o))
+					}
+
+					got, err := p.FindPath(context.Background())
+					assert.NoError(t, err)
+
+					want, err := NewGridPathfinder(p.Grid(), VelocityDistance, r).FindPath(context.Background(), starts, goal)
+					if err != nil {
+						// all the finish cells are covered
+						want = nil
+					}
+
+					assert.Equal(t, want == nil, got == nil, "starts %v, finish %v, changes %v", starts, finish, changes)
+					if got != nil && want != nil {
+						assert.Equal(t, want.Cost, got.Cost, "starts %v, finish %v, changes %v", starts, finish, changes)
+
+						// the repaired race lands on the current track only
+						for _, h := range got.Hops {
+							assert.True(t, p.Grid().IsAvailable(h.X, h.Y), "hop %v, changes %v", h, changes)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestIncrementalPlanner_FindPath_Repair(t *testing.T) {
+	// the race winds between the walls, so the search from scratch expands most of the track
+	grid := NewGrid(30, 30, Obstacle{X1: 0, X2: 22, Y1: 10, Y2: 10}, Obstacle{X1: 7, X2: 29, Y1: 20, Y2: 20})
+	starts, goal := []*Cell{{X: 1, Y: 1}}, NewLandingGoal(&Cell{X: 28, Y: 28})
+	rules := Rules{FlightCollision: true}
+
+	p, err := NewIncrementalPlanner(grid, VelocityDistance, rules, starts, goal)
+	require.NoError(t, err)
+
+	got, err := p.FindPath(context.Background())
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		// the race is blocked close to the finish
+		hop := got.Hops[len(got.Hops)-2]
+		require.NoError(t, p.AddObstacle(Obstacle{X1: hop.X, X2: hop.X, Y1: hop.Y, Y2: hop.Y}))
+
+		var repair SearchStats
+		got, err = p.FindPath(context.Background(), WithStats(&repair))
+		require.NoError(t, err)
+
+		var full SearchStats
+		want, err := NewGridPathfinder(p.Grid(), VelocityDistance, rules).FindPath(context.Background(), starts, goal, WithStats(&full))
+		require.NoError(t, err)
+
+		assert.Equal(t, want.Cost, got.Cost)
+		assert.Less(t, repair.Expanded*10, full.Expanded)
+	}
+}
+
+func TestIncrementalPlanner_FindPath_Aborted(t *testing.T) {
+	grid := NewGrid(10, 50)
+	starts, goal := []*Cell{{X: 0, Y: 5}}, NewLandingGoal(&Cell{X: 49, Y: 5})
+
+	p, err := NewIncrementalPlanner(grid, VelocityDistance, Rules{}, starts, goal)
+	require.NoError(t, err)
+
+	got, err := p.FindPath(context.Background(), WithMaxExpansions(3))
+	assert.ErrorIs(t, err, ErrMaxExpansions)
+	assert.Nil(t, got)
+
+	// the next search resumes the aborted one
+	got, err = p.FindPath(context.Background())
+	assert.NoError(t, err)
+
+	want, err := NewGridPathfinder(grid, VelocityDistance, Rules{}).FindPath(context.Background(), starts, goal)
+	require.NoError(t, err)
+	assert.Equal(t, want.Cost, got.Cost)
+}
+
+// TestIncrementalPlanner_FindPath_SingleCellEdits checks the races repaired after the single cells
+// of the track are covered and cleared one by one against the races found from scratch.
+func TestIncrementalPlanner_FindPath_SingleCellEdits(t *testing.T) {
+	starts, goal := []*Cell{{X: 0, Y: 5}}, NewLandingGoal(&Cell{X: 5, Y: 1}, &Cell{X: 4, Y: 7})
+
+	// the landings on the terrain are free, so the repaired costs could be too low
+	grid := NewGrid(6, 10).WithTerrain(Terrain{X1: 2, X2: 4, Y1: 0, Y2: 2, Cost: -1})
+	_, err := NewIncrementalPlanner(grid, VelocityDistance, Rules{}, starts, goal)
+	assert.ErrorContains(t, err, "incremental planning is not supported on grids with free landings")
+
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 300; i++ {
+		grid := NewGrid(6, 10).WithTerrain(Terrain{X1: 2, X2: 4, Y1: 0, Y2: 2, Cost: rnd.Intn(3)})
+		goal := NewLandingGoal(&Cell{X: 5 + rnd.Intn(5), Y: rnd.Intn(6)})
+
+		p, err := NewIncrementalPlanner(grid, VelocityDistance, Rules{}, starts, goal)
+		require.NoError(t, err)
+
+		var changes []Obstacle
+		for c := 0; c < 8; c++ {
+			x, y := rnd.Intn(grid.Cols), rnd.Intn(grid.Rows)
+			o := Obstacle{X1: x, X2: x, Y1: y, Y2: y}
+			changes = append(changes, o)
+
+			if rnd.Intn(3) == 0 {
+				require.NoError(t, p.RemoveObstacle(o))
+			} else {
+				require.NoError(t, p.AddObstacle(o))
+			}
+
+			got, err := p.FindPath(context.Background())
+			assert.NoError(t, err)
+
+			want, err := NewGridPathfinder(p.Grid(), VelocityDistance, Rules{}).FindPath(context.Background(), starts, goal)
+			if err != nil {
+				// the finish cell is covered
+				want = nil
+			}
+
+			assert.Equal(t, want == nil, got == nil, "finish %v, changes %v", goal.Cells(), changes)
+			if got != nil && want != nil {
+				assert.Equal(t, want.Cost, got.Cost, "finish %v, changes %v", goal.Cells(), changes)
+			}
+		}
+	}
+}
+
+func TestIncrementalPlanner_TimedObstacle(t *testing.T) {
+	p, err := NewIncrementalPlanner(NewGrid(1, 5), VelocityDistance, Rules{}, []*Cell{{X: 0, Y: 0}}, NewLandingGoal(&Cell{X: 4, Y: 0}))
+	require.NoError(t, err)
+
+	timed := Obstacle{X1: 2, X2: 2, Y1: 0, Y2: 0, ActiveFrom: 2}
+	assert.ErrorContains(t, p.AddObstacle(timed), "incremental planning does not support timed obstacles")
+	assert.ErrorContains(t, p.RemoveObstacle(timed), "incremental planning does not support timed obstacles")
+
+	// the track is not changed
+	assert.True(t, p.Grid().IsAvailable(2, 0))
+}
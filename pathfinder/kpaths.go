@@ -0,0 +1,303 @@
+package pathfinder
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Distinctness is the criterion of the races found by FindKPaths to be different from each other.
+type Distinctness int
+
+const (
+	// DistinctLandings makes the races different if they land on different cells:
+	// the races starting from different cells or landing on different cells at any hop are different.
+	DistinctLandings Distinctness = iota
+	// DistinctVelocities makes the races different if they are made with different velocities:
+	// the races with the same sequence of velocities starting from different cells are the same.
+	DistinctVelocities
+)
+
+// KPathfinder is a Pathfinder that can also find the alternative races.
+type KPathfinder interface {
+	Pathfinder
+
+	// FindKPaths returns up to k shortest races from any of the start cells to the finish
+	// different from each other by the given criterion, ordered by the number of hops.
+	// An error is returned if the grid has terrain.
+	FindKPaths(ctx context.Context, starts []*Cell, finish Goal, k int, distinct Distinctness, opts ...SearchOption) ([]*Solution, error)
+}
+
+// FindKPaths returns up to k shortest races from any of the start cells to the finish
+// different from each other by the given criterion, ordered by the number of hops.
+//
+// The races are found by the Yen's algorithm adapted to the states of the hopper.
+// See findKPaths for the details.
+func (pf *GridPathfinder) FindKPaths(ctx context.Context, starts []*Cell, finish Goal, k int, distinct Distinctness,
+	opts ...SearchOption) ([]*Solution, error) {
+	return findKPaths(ctx, pf.Grid, pf.Rules, starts, finish, k, distinct, opts)
+}
+
+// FindKPaths returns up to k shortest races from any of the start cells to the finish
+// different from each other by the given criterion, ordered by the number of hops.
+//
+// The races are found by the Yen's algorithm adapted to the states of the hopper.
+// See findKPaths for the details.
+func (pf *BreadthFirstPathfinder) FindKPaths(ctx context.Context, starts []*Cell, finish Goal, k int, distinct Distinctness,
+	opts ...SearchOption) ([]*Solution, error) {
+	return findKPaths(ctx, pf.Grid, pf.Rules, starts, finish, k, distinct, opts)
+}
+
+// race is a race found by the Yen's algorithm.
+type race struct {
+	// path holds the states of the race from the start state to the finishing one.
+	path []*Cell
+	// finish is the position of the finish cell the race is finished at.
+	finish Position
+}
+
+// key returns the key of the race distinguishing it by the given criterion.
+func (r *race) key(distinct Distinctness) string {
+	var sb strings.Builder
+
+	for i, c := range r.path {
+		switch {
+		case distinct == DistinctVelocities && i > 0:
+			sb.WriteString(c.Speed.String())
+		case distinct == DistinctLandings:
+			sb.WriteString(fmt.Sprintf("(%d,%d)", c.X, c.Y))
+		}
+	}
+
+	return sb.String()
+}
+
+// stateKey returns the key of the race distinguishing it by its states.
+func (r *race) stateKey() string {
+	var sb strings.Builder
+
+	for _, c := range r.path {
+		sb.WriteString(fmt.Sprintf("(%d,%d)%s", c.X, c.Y, c.Speed))
+	}
+
+	return sb.String()
+}
+
+// hasRoot reports whether the race starts with the given states.
+func (r *race) hasRoot(root []*Cell) bool {
+	if len(r.path) <= len(root) {
+		return false
+	}
+
+	for i, c := range root {
+		if r.path[i].State() != c.State() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// findKPaths returns up to k shortest races from any of the start cells to the finish
+// different from each other by the given criterion, ordered by the number of hops.
+//
+// The races are found by the Yen's algorithm over the states of the hopper:
+// starting from the shortest race, each next race is the shortest one among the candidates
+// deviating from the races found so far. A candidate follows a found race up to a spur state
+// and leaves it with a hop none of the found races with the same beginning makes,
+// never returning to the states before the spur state. Deviating at the very start means
+// starting from a start cell none of the found races starts from.
+// The races are ordered by the number of hops and the spur races are found by the breadth-first search,
+// so the grids with terrain, where the optimal race is the cheapest one rather than the shortest one, are not supported.
+//
+// The races different by their states but the same by the criterion are skipped.
+// If the search is aborted, the races found so far are returned together with the AbortedError.
+func findKPaths(ctx context.Context, grid *Grid, rules Rules, starts []*Cell, finish Goal, k int, distinct Distinctness,
+	opts []SearchOption) ([]*Solution, error) {
+	if grid == nil {
+		return nil, errors.New("grid must be provided")
+	}
+	if grid.Weighted() {
+		return nil, errors.New("alternative races are not supported on grids with terrain")
+	}
+	if k <= 0 {
+		return nil, errors.New("number of races must be positive")
+	}
+
+	initials, err := getEndpoints(grid, starts, finish)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &spurSearch{
+		ctx:      ctx,
+		grid:     grid,
+		rules:    rules,
+		finish:   finish,
+		settings: newSearchSettings(opts),
+	}
+	defer s.settings.report(&s.stats)
+
+	first, err := s.shortest(initials, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if first == nil {
+		// No path found
+		return nil, nil
+	}
+
+	// found holds the races found so far, different by their states,
+	// and candidates holds the races deviating from them
+	found := []*race{first}
+	var candidates []*race
+	known := map[string]bool{first.stateKey(): true}
+
+	solutions := []*Solution{newSolution(grid, first.path, first.finish)}
+	keys := map[string]bool{first.key(distinct): true}
+
+	for len(solutions) < k {
+		last := found[len(found)-1]
+
+		// deviate from the last race at each of its states but the finishing one,
+		// or at the very start (j = -1)
+		for j := -1; j < len(last.path)-1; j++ {
+			root := last.path[:j+1]
+
+			// the hops (or the starts) already taken after the root by the races found so far
+			taken := make(map[State]bool)
+			for _, r := range found {
+				if r.hasRoot(root) {
+					taken[r.path[j+1].State()] = true
+				}
+			}
+
+			var spur *race
+			if j < 0 {
+				var sources []*Cell
+				for _, c := range initials {
+					if !taken[c.State()] {
+						sources = append(sources, c)
+					}
+				}
+
+				spur, err = s.shortest(sources, nil, nil)
+			} else {
+				// the race never returns to the states of the root
+				banned := make(map[State]bool, j)
+				for _, c := range root[:j] {
+					banned[c.State()] = true
+				}
+
+				spur, err = s.shortest([]*Cell{root[j]}, banned, taken)
+			}
+			if err != nil {
+				return solutions, err
+			}
+			if spur == nil {
+				continue
+			}
+
+			candidate := &race{
+				path:   append(append([]*Cell{}, root[:max(j, 0)]...), spur.path...),
+				finish: spur.finish,
+			}
+			if key := candidate.stateKey(); !known[key] {
+				known[key] = true
+				candidates = append(candidates, candidate)
+			}
+		}
+
+		if len(candidates) == 0 {
+			break
+		}
+
+		// take the shortest candidate (the earliest one among the equally short ones)
+		best := 0
+		for i, c := range candidates {
+			if len(c.path) < len(candidates[best].path) {
+				best = i
+			}
+		}
+		next := candidates[best]
+		candidates = append(candidates[:best], candidates[best+1:]...)
+
+		found = append(found, next)
+		if key := next.key(distinct); !keys[key] {
+			keys[key] = true
+			solutions = append(solutions, newSolution(grid, next.path, next.finish))
+		}
+	}
+
+	return solutions, nil
+}
+
+// spurSearch finds the spur races of the Yen's algorithm.
+type spurSearch struct {
+	ctx      context.Context
+	grid     *Grid
+	rules    Rules
+	finish   Goal
+	settings *searchSettings
+
+	// stats holds the statistics of all the spur searches.
+	stats SearchStats
+}
+
+// shortest returns the shortest race from any of the given states to the finish by the breadth-first search,
+// avoiding the banned states and not making the first hop to the taken states.
+// It returns nil if the finish cannot be reached.
+func (s *spurSearch) shortest(sources []*Cell, banned, taken map[State]bool) (*race, error) {
+	visited := make(map[State]*node, len(sources))
+
+	queue := make([]*node, 0, len(sources))
+	for _, source := range sources {
+		if _, ok := visited[source.State()]; ok {
+			continue
+		}
+
+		n := newNode(source, nil)
+		s.stats.Generated++
+		if at, ok := s.rules.FinishesAt(source, s.finish); ok {
+			return &race{path: n.path(), finish: at}, nil
+		}
+
+		visited[n.state()] = n
+		queue = append(queue, n)
+		s.stats.open(len(queue))
+	}
+
+	for len(queue) > 0 {
+		if err := s.settings.check(s.ctx, s.stats.Expanded, len(queue)); err != nil {
+			return nil, &AbortedError{Reason: err}
+		}
+
+		current := queue[0]
+		queue = queue[1:]
+		s.stats.Expanded++
+
+		for _, next := range s.grid.GetNeighbors(current.cell, s.rules) {
+			if _, ok := visited[next.State()]; ok || banned[next.State()] {
+				continue
+			}
+			if current.parent == nil && taken[next.State()] {
+				continue
+			}
+
+			neighbor := newNode(next, current)
+			visited[neighbor.state()] = neighbor
+			s.stats.Generated++
+
+			if at, ok := s.rules.FinishesAt(next, s.finish); ok {
+				return &race{path: neighbor.path(), finish: at}, nil
+			}
+
+			queue = append(queue, neighbor)
+			s.stats.open(len(queue))
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,228 @@
+package pathfinder
+
+import (
+	"context"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKPathfinder_FindKPaths(t *testing.T) {
+	// the column of two start cells and the column of two finish cells
+	starts := []*Cell{{X: 0, Y: 0}, {X: 0, Y: 1}}
+	finish := NewLandingGoal(&Cell{X: 2, Y: 0}, &Cell{X: 2, Y: 1})
+
+	tests := []struct {
+		name     string
+		grid     *Grid
+		starts   []*Cell
+		finish   Goal
+		k        int
+		distinct Distinctness
+		want     []string
+		err      error
+	}{
+		{
+			name:   "shortest race only",
+			grid:   NewGrid(1, 5),
+			starts: []*Cell{{X: 0, Y: 0}},
+			finish: NewLandingGoal(&Cell{X: 4, Y: 0}),
+			k:      1,
+			want:   []string{"(0,0) (1,0) (2,0) (4,0)"},
+		},
+		{
+			name:   "races ordered by hops",
+			grid:   NewGrid(1, 5),
+			starts: []*Cell{{X: 0, Y: 0}},
+			finish: NewLandingGoal(&Cell{X: 4, Y: 0}),
+			k:      3,
+			want: []string{
+				"(0,0) (1,0) (2,0) (4,0)",
+				"(0,0) (1,0) (3,0) (4,0)",
+				"(0,0) (1,0) (2,0) (3,0) (4,0)",
+			},
+		},
+		{
+			name:   "fewer races than requested",
+			grid:   NewGrid(1, 5),
+			starts: []*Cell{{X: 0, Y: 0}},
+			finish: NewLandingGoal(&Cell{X: 4, Y: 0}),
+			k:      10,
+			want: []string{
+				"(0,0) (1,0) (2,0) (4,0)",
+				"(0,0) (1,0) (3,0) (4,0)",
+				"(0,0) (1,0) (2,0) (3,0) (4,0)",
+			},
+		},
+		{
+			name:     "races with different landing cells",
+			grid:     NewGrid(2, 3),
+			starts:   starts,
+			finish:   finish,
+			k:        6,
+			distinct: DistinctLandings,
+			want: []string{
+				"(0,0) (1,0) (2,0)",
+				"(0,1) (1,0) (2,0)",
+				"(0,0) (1,1) (2,1)",
+				"(0,0) (1,0) (2,1)",
+				"(0,1) (1,1) (2,0)",
+				"(0,1) (1,1) (2,1)",
+			},
+		},
+		{
+			name:     "races with different velocities",
+			grid:     NewGrid(2, 3),
+			starts:   starts,
+			finish:   finish,
+			k:        6,
+			distinct: DistinctVelocities,
+			want: []string{
+				"(0,0) (1,0) (2,0)",
+				"(0,1) (1,0) (2,0)",
+				"(0,0) (1,1) (2,1)",
+				"(0,0) (1,0) (2,1)",
+				"(0,1) (1,1) (2,0)",
+				// (0,1) (1,1) (2,1) hops just like (0,0) (1,0) (2,0)
+				"(0,0) (0,1) (1,1) (2,0)",
+			},
+		},
+		{
+			name:   "start at finish",
+			grid:   NewGrid(1, 3),
+			starts: []*Cell{{X: 1, Y: 0}},
+			finish: NewLandingGoal(&Cell{X: 1, Y: 0}),
+			k:      2,
+			want:   []string{"(1,0)"},
+		},
+		{
+			name: "no race",
+			grid: NewGrid(3, 3, []Obstacle{
+				{X1: 1, Y1: 0, X2: 1, Y2: 2},
+				{X1: 0, Y1: 1, X2: 2, Y2: 1},
+			}...),
+			starts: []*Cell{{X: 0, Y: 0}},
+			finish: NewLandingGoal(&Cell{X: 2, Y: 2}),
+			k:      3,
+			want:   nil,
+		},
+		{
+			name:   "non-positive number of races",
+			grid:   NewGrid(1, 5),
+			starts: []*Cell{{X: 0, Y: 0}},
+			finish: NewLandingGoal(&Cell{X: 4, Y: 0}),
+			k:      0,
+			err:    errors.New("number of races must be positive"),
+		},
+		{
+			name:   "start cell out of grid",
+			grid:   NewGrid(1, 5),
+			starts: []*Cell{{X: 5, Y: 0}},
+			finish: NewLandingGoal(&Cell{X: 4, Y: 0}),
+			k:      3,
+			err:    errors.New("start cell is out of grid"),
+		},
+		{
+			name:   "terrain",
+			grid:   NewGrid(1, 7).WithTerrain(Terrain{X1: 3, X2: 3, Y1: 0, Y2: 0, Cost: 5}),
+			starts: []*Cell{{X: 0, Y: 0}},
+			finish: NewLandingGoal(&Cell{X: 6, Y: 0}),
+			k:      1,
+			err:    errors.New("alternative races are not supported on grids with terrain"),
+		},
+	}
+	for _, test := range tests {
+		pathfinders := map[string]KPathfinder{
+			"astar": NewGridPathfinder(test.grid, VelocityDistance, Rules{}).(KPathfinder),
+			"bfs":   NewBreadthFirstPathfinder(test.grid, Rules{}).(KPathfinder),
+		}
+		for name, pf := range pathfinders {
+			t.Run(test.name+"/"+name, func(t *testing.T) {
+				got, err := pf.FindKPaths(context.Background(), test.starts, test.finish, test.k, test.distinct)
+
+				if test.err != nil {
+					assert.ErrorContains(t, err, test.err.Error())
+					assert.Nil(t, got)
+					return
+				}
+
+				assert.NoError(t, err)
+
+				var races []string
+				for _, s := range got {
+					races = append(races, racePositions(s))
+				}
+				assert.Equal(t, test.want, races)
+			})
+		}
+	}
+}
+
+// TestFindKPaths_Reference checks the numbers of hops of the races
+// against the exhaustive enumeration of the races on random tracks.
+func TestFindKPaths_Reference(t *testing.T) {
+	const k = 5
+
+	rnd := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 200; i++ {
+		grid, starts, finish := randomTrack(rnd, 4, 4)
+		goal := NewLandingGoal(finish...)
+
+		got, err := findKPaths(context.Background(), grid, Rules{}, starts, goal, k, DistinctLandings, nil)
+		assert.NoError(t, err)
+		if len(got) < k || got[k-1].Len() > 5 {
+			// too many races to enumerate
+			continue
+		}
+
+		var want []int
+		seen := make(map[State]bool)
+		for _, s := range starts {
+			if !seen[s.State()] {
+				seen[s.State()] = true
+				enumerateSimpleRaces(grid, []*Cell{grid.GetCell(s.X, s.Y)}, goal, got[k-1].Len(), &want)
+			}
+		}
+		slices.Sort(want)
+
+		var hops []int
+		unique := make(map[string]bool)
+		for _, s := range got {
+			hops = append(hops, s.Len())
+			unique[racePositions(s)] = true
+		}
+
+		assert.Equal(t, want[:k], hops, "starts %v, finish %v", starts, finish)
+		assert.Len(t, unique, k)
+	}
+}
+
+func TestFindKPaths_Aborted(t *testing.T) {
+	got, err := findKPaths(context.Background(), NewGrid(1, 30), Rules{}, []*Cell{{X: 0, Y: 0}},
+		NewLandingGoal(&Cell{X: 29, Y: 0}), 3, DistinctLandings, []SearchOption{WithMaxExpansions(5)})
+	assert.Nil(t, got)
+	assert.ErrorIs(t, err, ErrMaxExpansions)
+}
+
+// enumerateSimpleRaces appends the numbers of hops of the races following the given states,
+// finishing with up to the given number of hops and never returning to the same state, to the hops.
+func enumerateSimpleRaces(grid *Grid, path []*Cell, finish Goal, maxHops int, hops *[]int) {
+	last := path[len(path)-1]
+	if (Rules{}).Finishes(last, finish) {
+		*hops = append(*hops, len(path)-1)
+		return
+	}
+	if len(path)-1 == maxHops {
+		return
+	}
+
+	for _, next := range grid.GetNeighbors(last, Rules{}) {
+		if !slices.ContainsFunc(path, func(c *Cell) bool { return c.State() == next.State() }) {
+			enumerateSimpleRaces(grid, append(path, next), finish, maxHops, hops)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package pathfinder
+
+// walkLine visits the cells a hopper flies over while hopping from cell (x0, y0) to cell (x1, y1)
+// in the order of the flight, including both the departure and the landing cells.
+//
+// The hop is considered to be a straight segment between the centers of the cells,
+// and a cell is visited if the segment crosses its interior (i.e., the supercover of the segment).
+// When the segment passes exactly through a corner shared by four cells,
+// only the cells on the diagonal are visited, as the segment merely touches the other two.
+//
+// The walk stops as soon as the visit function returns false,
+// in which case walkLine returns false as well.
+func walkLine(x0, y0, x1, y1 int, visit func(x, y int) bool) bool {
+	dx, dy := x1-x0, y1-y0
+
+	sx, sy := 1, 1
+	if dx < 0 {
+		dx, sx = -dx, -1
+	}
+	if dy < 0 {
+		dy, sy = -dy, -1
+	}
+
+	x, y := x0, y0
+	if !visit(x, y) {
+		return false
+	}
+
+	for nx, ny := 0, 0; nx < dx || ny < dy; {
+		// compare the distances to the next vertical and horizontal grid lines
+		// scaled by 2*dx*dy to stay in integers
+		switch decision := (1+2*nx)*dy - (1+2*ny)*dx; {
+		case decision == 0:
+			// the segment passes exactly through a corner
+			x += sx
+			y += sy
+			nx++
+			ny++
+		case decision < 0:
+			x += sx
+			nx++
+		default:
+			y += sy
+			ny++
+		}
+
+		if !visit(x, y) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// clearFlight reports whether the hopper hopping from cell (x0, y0) to cell (x1, y1) flies over
+// the available cells only.
+//
+// The departure cell is not checked: the hopper stands on it already, having landed on it before
+// (or resting on a start cell), so an obstacle showing up on it at the time of the landing
+// does not stop the hopper leaving it.
+func clearFlight(x0, y0, x1, y1 int, available func(x, y int) bool) bool {
+	return walkLine(x0, y0, x1, y1, func(x, y int) bool {
+		return x == x0 && y == y0 || available(x, y)
+	})
+}
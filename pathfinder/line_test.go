@@ -0,0 +1,80 @@
+package pathfinder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalkLine(t *testing.T) {
+	type cell struct {
+		x, y int
+	}
+
+	tests := []struct {
+		name           string
+		x0, y0, x1, y1 int
+		want           []cell
+	}{
+		{
+			name: "same cell",
+			x0:   1, y0: 1, x1: 1, y1: 1,
+			want: []cell{{1, 1}},
+		},
+		{
+			name: "horizontal hop",
+			x0:   0, y0: 0, x1: 3, y1: 0,
+			want: []cell{{0, 0}, {1, 0}, {2, 0}, {3, 0}},
+		},
+		{
+			name: "vertical hop backwards",
+			x0:   2, y0: 3, x1: 2, y1: 1,
+			want: []cell{{2, 3}, {2, 2}, {2, 1}},
+		},
+		{
+			name: "diagonal hop through corners",
+			x0:   0, y0: 0, x1: 2, y1: 2,
+			want: []cell{{0, 0}, {1, 1}, {2, 2}},
+		},
+		{
+			name: "knight hop",
+			x0:   0, y0: 0, x1: 2, y1: 1,
+			want: []cell{{0, 0}, {1, 0}, {1, 1}, {2, 1}},
+		},
+		{
+			name: "knight hop backwards",
+			x0:   2, y0: 1, x1: 0, y1: 0,
+			want: []cell{{2, 1}, {1, 1}, {1, 0}, {0, 0}},
+		},
+		{
+			name: "steep hop through a corner",
+			x0:   0, y0: 0, x1: 1, y1: 3,
+			want: []cell{{0, 0}, {0, 1}, {1, 2}, {1, 3}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []cell
+
+			ok := walkLine(test.x0, test.y0, test.x1, test.y1, func(x, y int) bool {
+				got = append(got, cell{x, y})
+				return true
+			})
+
+			assert.True(t, ok)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestWalkLine_Stop(t *testing.T) {
+	visited := 0
+
+	ok := walkLine(0, 0, 3, 0, func(x, y int) bool {
+		visited++
+		return x < 1
+	})
+
+	assert.False(t, ok)
+	assert.Equal(t, 2, visited)
+}
@@ -0,0 +1,67 @@
+package pathfinder
+
+// node holds the search bookkeeping of a single state of the hopper.
+//
+// Nodes are created by each search for the states it discovers
+// and are never stored in the grid, so a grid holds the track only
+// and can be shared by any number of searches, including concurrent ones.
+type node struct {
+	// cell is the cell the hopper has landed on together with the velocity it has landed with.
+	cell *Cell
+
+	// open indicates whether the state has been checked and evaluated
+	// during path finding algorithm iteration.
+	open bool
+	// closed indicates whether the state has been processed
+	// during path finding algorithm iteration.
+	closed bool
+
+	// gCost is the cost of the path from the start state to this state.
+	// In the case of Hopping Race game, it is the number of hops from the start cell to this state
+	// (or the sum of the costs of landing on their cells, if the grid has terrain).
+	gCost int
+	// hCost is the heuristic cost of the path from this state to the finish.
+	// hCost is calculated by the heuristic function
+	// and represents the estimated number of hops from this state to the finish on a clear grid
+	// (multiplied by the minimal landing cost, if the grid has terrain).
+	hCost int
+	// fCost is the sum of gCost and hCost.
+	fCost int
+
+	// parent is the node from which the hopper reached this state.
+	parent *node
+
+	// entry is the entry of the node in the open states priority queue;
+	// it is nil if the node is not in the queue.
+	entry *queueEntry
+}
+
+// newNode returns a new node of the state of the hopper standing on the given cell
+// reached from the given parent node.
+func newNode(cell *Cell, parent *node) *node {
+	n := &node{
+		cell:   cell,
+		parent: parent,
+	}
+	if parent != nil {
+		n.gCost = parent.gCost + 1
+	}
+
+	return n
+}
+
+// state returns the state of the hopper the node holds the bookkeeping of.
+func (n *node) state() State {
+	return n.cell.State()
+}
+
+// path returns the cells of the states from the start state to the state of the node.
+func (n *node) path() []*Cell {
+	path := make([]*Cell, 0, n.gCost+1)
+
+	for ; n != nil; n = n.parent {
+		path = append(path, n.cell)
+	}
+
+	return reversePath(path)
+}
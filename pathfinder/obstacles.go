@@ -0,0 +1,101 @@
+package pathfinder
+
+import (
+	"slices"
+	"sort"
+)
+
+// obstacleIndex is a sparse representation of the obstacles of a grid.
+//
+// The rows of the grid are split into bands of consecutive rows covered by the same obstacles,
+// and each band holds the sorted disjoint spans of the columns occupied within it.
+// The memory taken by the index depends on the number of obstacles only, not on the size of the grid,
+// and a cell is looked up by two binary searches.
+type obstacleIndex struct {
+	// bands are the bands of rows having occupied cells, sorted by rows.
+	bands []band
+}
+
+// band is a range of grid rows with the same occupied columns.
+type band struct {
+	// y1 and y2 are the first and the last rows of the band.
+	y1, y2 int
+	// spans are the occupied columns of the band, sorted and disjoint.
+	spans []span
+}
+
+// span is a range of occupied columns.
+type span struct {
+	// x1 and x2 are the first and the last columns of the span.
+	x1, x2 int
+}
+
+// newObstacleIndex returns the index of the given obstacles.
+// The obstacles are expected to be clamped to the grid already.
+func newObstacleIndex(obstacles []Obstacle) *obstacleIndex {
+	// the set of obstacles covering a row changes at the first row of an obstacle
+	// and right after the last one only
+	var bounds []int
+	for _, o := range obstacles {
+		bounds = append(bounds, o.Y1, o.Y2+1)
+	}
+	slices.Sort(bounds)
+	bounds = slices.Compact(bounds)
+
+	idx := &obstacleIndex{}
+
+	for i := 0; i+1 < len(bounds); i++ {
+		y1, y2 := bounds[i], bounds[i+1]-1
+
+		var spans []span
+		for _, o := range obstacles {
+			if o.Y1 <= y1 && o.Y2 >= y2 {
+				spans = append(spans, span{x1: o.X1, x2: o.X2})
+			}
+		}
+		if len(spans) == 0 {
+			continue
+		}
+
+		idx.bands = append(idx.bands, band{y1: y1, y2: y2, spans: mergeSpans(spans)})
+	}
+
+	return idx
+}
+
+// mergeSpans sorts the given spans and merges the overlapping and adjacent ones.
+func mergeSpans(spans []span) []span {
+	slices.SortFunc(spans, func(a, b span) int {
+		return a.x1 - b.x1
+	})
+
+	merged := spans[:1]
+	for _, s := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if s.x1 <= last.x2+1 {
+			last.x2 = max(last.x2, s.x2)
+			continue
+		}
+
+		merged = append(merged, s)
+	}
+
+	return merged
+}
+
+// isOccupied reports whether the cell at the specified coordinates is covered by an obstacle.
+func (idx *obstacleIndex) isOccupied(x, y int) bool {
+	i := sort.Search(len(idx.bands), func(i int) bool {
+		return idx.bands[i].y2 >= y
+	})
+	if i == len(idx.bands) || idx.bands[i].y1 > y {
+		return false
+	}
+
+	spans := idx.bands[i].spans
+	j := sort.Search(len(spans), func(j int) bool {
+		return spans[j].x2 >= x
+	})
+
+	return j < len(spans) && spans[j].x1 <= x
+}
@@ -0,0 +1,78 @@
+package pathfinder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewObstacleIndex(t *testing.T) {
+	tests := []struct {
+		name      string
+		obstacles []Obstacle
+		want      []band
+	}{
+		{
+			name:      "no obstacles",
+			obstacles: nil,
+			want:      nil,
+		},
+		{
+			name: "single obstacle",
+			obstacles: []Obstacle{
+				{X1: 1, Y1: 2, X2: 3, Y2: 4},
+			},
+			want: []band{
+				{y1: 2, y2: 4, spans: []span{{x1: 1, x2: 3}}},
+			},
+		},
+		{
+			name: "overlapping obstacles",
+			obstacles: []Obstacle{
+				{X1: 0, Y1: 0, X2: 2, Y2: 2},
+				{X1: 2, Y1: 1, X2: 4, Y2: 3},
+			},
+			want: []band{
+				{y1: 0, y2: 0, spans: []span{{x1: 0, x2: 2}}},
+				{y1: 1, y2: 2, spans: []span{{x1: 0, x2: 4}}},
+				{y1: 3, y2: 3, spans: []span{{x1: 2, x2: 4}}},
+			},
+		},
+		{
+			name: "adjacent and separate obstacles",
+			obstacles: []Obstacle{
+				{X1: 7, Y1: 0, X2: 8, Y2: 0},
+				{X1: 3, Y1: 0, X2: 4, Y2: 0},
+				{X1: 0, Y1: 0, X2: 2, Y2: 0},
+				{X1: 0, Y1: 5, X2: 0, Y2: 5},
+			},
+			want: []band{
+				{y1: 0, y2: 0, spans: []span{{x1: 0, x2: 4}, {x1: 7, x2: 8}}},
+				{y1: 5, y2: 5, spans: []span{{x1: 0, x2: 0}}},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := newObstacleIndex(test.obstacles)
+			assert.Equal(t, test.want, got.bands)
+		})
+	}
+}
+
+func TestObstacleIndex_IsOccupied(t *testing.T) {
+	idx := newObstacleIndex([]Obstacle{
+		{X1: 0, Y1: 0, X2: 2, Y2: 2},
+		{X1: 5, Y1: 1, X2: 6, Y2: 1},
+	})
+
+	assert.True(t, idx.isOccupied(0, 0))
+	assert.True(t, idx.isOccupied(2, 2))
+	assert.True(t, idx.isOccupied(5, 1))
+	assert.True(t, idx.isOccupied(6, 1))
+	assert.False(t, idx.isOccupied(3, 1))
+	assert.False(t, idx.isOccupied(7, 1))
+	assert.False(t, idx.isOccupied(5, 0))
+	assert.False(t, idx.isOccupied(0, 3))
+	assert.False(t, idx.isOccupied(-1, -1))
+}
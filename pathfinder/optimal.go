@@ -0,0 +1,176 @@
+package pathfinder
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// OptimalRaces holds all the races finishing with the minimal number of hops.
+type OptimalRaces struct {
+	// Hops is the minimal number of hops needed to finish the race.
+	Hops int
+	// Count is the number of the optimal races.
+	// Two races are different if their histories of states (i.e., landing cells together with velocities) differ,
+	// so the races starting from different start cells are always different.
+	Count *big.Int
+	// Races holds the optimal races up to the requested limit.
+	Races []*Solution
+}
+
+// Unique reports whether there is a single optimal race.
+func (r *OptimalRaces) Unique() bool {
+	return r != nil && r.Count.IsInt64() && r.Count.Int64() == 1
+}
+
+// countNode holds the bookkeeping of a state for counting the optimal races leading to it.
+type countNode struct {
+	*node
+
+	// count is the number of the optimal races leading to the state.
+	count big.Int
+	// preds are the states of the previous layer the state is reached from with a single hop.
+	preds []*countNode
+}
+
+// CountOptimalRaces counts the races from any of the start cells to the finish
+// taking the minimal number of hops, and returns up to limit of them.
+//
+// The states of the hopper are explored layer by layer (i.e., by the number of hops),
+// and each state gets the sum of the numbers of the races leading to its predecessors
+// in the previous layer. The search stops at the first layer having the states finishing the race,
+// so the numbers of the races to these states sum up to the number of the optimal races.
+//
+// The races are optimal by the number of hops, so the terrain of the grid is ignored.
+// It returns nil and no error if the finish cannot be reached from any start cell.
+// The search may be limited by the search options just like Pathfinder.FindPath;
+// the partial race of an aborted search leads to the discovered state with the lowest VelocityDistance estimate.
+func CountOptimalRaces(ctx context.Context, grid *Grid, starts []*Cell, finish Goal, rules Rules,
+	limit int, opts ...SearchOption) (*OptimalRaces, error) {
+	if grid == nil {
+		return nil, errors.New("grid must be provided")
+	}
+
+	initials, err := getEndpoints(grid, starts, finish)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := newSearchSettings(opts)
+
+	var stats SearchStats
+	defer settings.report(&stats)
+
+	// visited holds the bookkeeping of every state discovered so far
+	visited := make(map[State]*countNode, len(initials))
+
+	var layer []*countNode
+	for _, initial := range initials {
+		if _, ok := visited[initial.State()]; ok {
+			continue
+		}
+
+		n := &countNode{node: newNode(initial, nil)}
+		n.count.SetInt64(1)
+
+		visited[n.state()] = n
+		layer = append(layer, n)
+		stats.Generated++
+	}
+	stats.open(len(layer))
+
+	for hops := 0; len(layer) > 0; hops++ {
+		if races := finishingRaces(grid, layer, finish, rules, limit); races != nil {
+			races.Hops = hops
+			return races, nil
+		}
+
+		var next []*countNode
+		for _, current := range layer {
+			if err := settings.check(ctx, stats.Expanded, len(next)); err != nil {
+				return nil, abortCount(err, grid, visited, finish, rules)
+			}
+			stats.Expanded++
+
+			for _, c := range grid.GetNeighbors(current.cell, rules) {
+				neighbor, ok := visited[c.State()]
+				if !ok {
+					neighbor = &countNode{node: newNode(c, current.node)}
+					visited[neighbor.state()] = neighbor
+					next = append(next, neighbor)
+					stats.Generated++
+					stats.open(len(next))
+				}
+
+				// only the hops to the next layer lead along the optimal races
+				if neighbor.gCost != hops+1 {
+					continue
+				}
+
+				neighbor.count.Add(&neighbor.count, &current.count)
+				neighbor.preds = append(neighbor.preds, current)
+			}
+		}
+
+		layer = next
+	}
+
+	// No path found
+	return nil, nil
+}
+
+// finishingRaces returns the optimal races finishing at the states of the given layer (up to limit of them),
+// or nil if none of the states finishes the race.
+func finishingRaces(grid *Grid, layer []*countNode, finish Goal, rules Rules, limit int) *OptimalRaces {
+	var races *OptimalRaces
+
+	for _, n := range layer {
+		at, ok := rules.FinishesAt(n.cell, finish)
+		if !ok {
+			continue
+		}
+
+		if races == nil {
+			races = &OptimalRaces{Count: new(big.Int)}
+		}
+		races.Count.Add(races.Count, &n.count)
+
+		listRaces(grid, n, nil, at, limit, &races.Races)
+	}
+
+	return races
+}
+
+// listRaces appends the races leading to the given node followed by the given states
+// and finishing at the given position to the races, until there are limit of them.
+func listRaces(grid *Grid, n *countNode, tail []*Cell, at Position, limit int, races *[]*Solution) {
+	if len(*races) >= limit {
+		return
+	}
+
+	tail = append(tail, n.cell)
+
+	if len(n.preds) == 0 {
+		// the tail holds the states from the last one to the start state
+		path := make([]*Cell, len(tail))
+		copy(path, tail)
+
+		*races = append(*races, newSolution(grid, reversePath(path), at))
+		return
+	}
+
+	for _, pred := range n.preds {
+		listRaces(grid, pred, tail, at, limit, races)
+	}
+}
+
+// abortCount returns the error of the counting search aborted for the given reason.
+func abortCount(reason error, grid *Grid, visited map[State]*countNode, finish Goal, rules Rules) error {
+	nodes := make(map[State]*node, len(visited))
+	for s, n := range visited {
+		nodes[s] = n.node
+	}
+
+	return abort(reason, grid, nodes, VelocityDistance, finish, rules)
+}
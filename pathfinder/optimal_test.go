@@ -0,0 +1,235 @@
+package pathfinder
+
+import (
+	"context"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountOptimalRaces(t *testing.T) {
+	type want struct {
+		hops  int
+		count int64
+		races []string
+	}
+
+	tests := []struct {
+		name   string
+		grid   *Grid
+		starts []*Cell
+		finish Goal
+		rules  Rules
+		limit  int
+		want   *want
+		err    error
+	}{
+		{
+			name:   "unique race",
+			grid:   NewGrid(1, 3),
+			starts: []*Cell{{X: 0, Y: 0}},
+			finish: NewLandingGoal(&Cell{X: 2, Y: 0}),
+			limit:  10,
+			want: &want{
+				hops:  2,
+				count: 1,
+				races: []string{"(0,0) (1,0) (2,0)"},
+			},
+		},
+		{
+			name:   "several races",
+			grid:   NewGrid(1, 5),
+			starts: []*Cell{{X: 0, Y: 0}},
+			finish: NewLandingGoal(&Cell{X: 4, Y: 0}),
+			limit:  10,
+			want: &want{
+				hops:  3,
+				count: 2,
+				races: []string{"(0,0) (1,0) (2,0) (4,0)", "(0,0) (1,0) (3,0) (4,0)"},
+			},
+		},
+		{
+			name:   "races listed up to limit",
+			grid:   NewGrid(1, 5),
+			starts: []*Cell{{X: 0, Y: 0}},
+			finish: NewLandingGoal(&Cell{X: 4, Y: 0}),
+			limit:  1,
+			want: &want{
+				hops:  3,
+				count: 2,
+				races: []string{"(0,0) (1,0) (2,0) (4,0)"},
+			},
+		},
+		{
+			name:   "races counted only",
+			grid:   NewGrid(1, 5),
+			starts: []*Cell{{X: 0, Y: 0}},
+			finish: NewLandingGoal(&Cell{X: 4, Y: 0}),
+			limit:  0,
+			want: &want{
+				hops:  3,
+				count: 2,
+			},
+		},
+		{
+			name:   "races from several start cells",
+			grid:   NewGrid(1, 5),
+			starts: []*Cell{{X: 0, Y: 0}, {X: 4, Y: 0}},
+			finish: NewLandingGoal(&Cell{X: 2, Y: 0}),
+			limit:  10,
+			want: &want{
+				hops:  2,
+				count: 2,
+				races: []string{"(0,0) (1,0) (2,0)", "(4,0) (3,0) (2,0)"},
+			},
+		},
+		{
+			name:   "start at finish",
+			grid:   NewGrid(1, 5),
+			starts: []*Cell{{X: 2, Y: 0}},
+			finish: NewLandingGoal(&Cell{X: 2, Y: 0}),
+			limit:  10,
+			want: &want{
+				hops:  0,
+				count: 1,
+				races: []string{"(2,0)"},
+			},
+		},
+		{
+			name: "no race",
+			grid: NewGrid(3, 3, []Obstacle{
+				{X1: 1, Y1: 0, X2: 1, Y2: 2},
+				{X1: 0, Y1: 1, X2: 2, Y2: 1},
+			}...),
+			starts: []*Cell{{X: 0, Y: 0}},
+			finish: NewLandingGoal(&Cell{X: 2, Y: 2}),
+			limit:  10,
+			want:   nil,
+		},
+		{
+			name:   "nil grid",
+			grid:   nil,
+			starts: []*Cell{{X: 0, Y: 0}},
+			finish: NewLandingGoal(&Cell{X: 2, Y: 2}),
+			err:    errors.New("grid must be provided"),
+		},
+		{
+			name:   "start cell out of grid",
+			grid:   NewGrid(3, 3),
+			starts: []*Cell{{X: 3, Y: 0}},
+			finish: NewLandingGoal(&Cell{X: 2, Y: 2}),
+			err:    errors.New("start cell is out of grid"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := CountOptimalRaces(context.Background(), test.grid, test.starts, test.finish, test.rules, test.limit)
+
+			if test.err != nil {
+				assert.ErrorContains(t, err, test.err.Error())
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NoError(t, err)
+			if test.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.Equal(t, test.want.hops, got.Hops)
+			assert.Equal(t, big.NewInt(test.want.count), got.Count)
+			assert.Equal(t, test.want.count == 1, got.Unique())
+
+			var races []string
+			for _, r := range got.Races {
+				assert.Equal(t, test.want.hops, r.Len())
+				races = append(races, racePositions(r))
+			}
+			assert.ElementsMatch(t, test.want.races, races)
+		})
+	}
+}
+
+// TestCountOptimalRaces_Reference checks the number of the optimal races
+// against the exhaustive enumeration of the races on random tracks.
+func TestCountOptimalRaces_Reference(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 200; i++ {
+		grid, starts, finish := randomTrack(rnd, 5, 5)
+		goal := NewLandingGoal(finish...)
+
+		solution, err := NewBreadthFirstPathfinder(grid, Rules{}).FindPath(context.Background(), starts, goal)
+		assert.NoError(t, err)
+
+		got, err := CountOptimalRaces(context.Background(), grid, starts, goal, Rules{}, 1000)
+		assert.NoError(t, err)
+
+		if solution == nil {
+			assert.Nil(t, got)
+			continue
+		}
+		if solution.Len() > 5 {
+			// too many races to enumerate
+			continue
+		}
+
+		want := 0
+		seen := make(map[State]bool)
+		for _, s := range starts {
+			if !seen[s.State()] {
+				seen[s.State()] = true
+				want += enumerateRaces(grid, s, goal, solution.Len())
+			}
+		}
+
+		assert.Equal(t, solution.Len(), got.Hops)
+		assert.Equal(t, big.NewInt(int64(want)), got.Count, "starts %v, finish %v", starts, finish)
+		assert.Equal(t, min(want, 1000), len(got.Races))
+	}
+}
+
+func TestCountOptimalRaces_Aborted(t *testing.T) {
+	got, err := CountOptimalRaces(context.Background(), NewGrid(1, 30), []*Cell{{X: 0, Y: 0}},
+		NewLandingGoal(&Cell{X: 29, Y: 0}), Rules{}, 10, WithMaxExpansions(5))
+	assert.Nil(t, got)
+	assert.ErrorIs(t, err, ErrMaxExpansions)
+
+	var aborted *AbortedError
+	if assert.True(t, errors.As(err, &aborted)) {
+		assert.NotNil(t, aborted.Partial)
+	}
+}
+
+// enumerateRaces returns the number of the races from the given cell finishing with the given number of hops
+// by trying every sequence of hops.
+func enumerateRaces(grid *Grid, cell *Cell, finish Goal, hops int) int {
+	if hops == 0 {
+		if (Rules{}).Finishes(cell, finish) {
+			return 1
+		}
+
+		return 0
+	}
+
+	count := 0
+	for _, next := range grid.GetNeighbors(cell, Rules{}) {
+		count += enumerateRaces(grid, next, finish, hops-1)
+	}
+
+	return count
+}
+
+// racePositions returns the positions of the states of the race separated by spaces.
+func racePositions(s *Solution) string {
+	positions := Position{X: s.Start.X, Y: s.Start.Y}.String()
+	for _, h := range s.Hops {
+		positions += " " + Position{X: h.X, Y: h.Y}.String()
+	}
+
+	return positions
+}
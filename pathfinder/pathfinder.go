@@ -2,26 +2,67 @@ package pathfinder
 
 import (
 	"container/heap"
+	"context"
 
 	"github.com/pkg/errors"
 )
 
-// Pathfinder is an interface for finding the shortest path between two cells.
+// Pathfinder is an interface for finding the shortest path from the start to the finish.
+//
+// FindPath accepts several start cells: the race may be started from any of them.
+// It returns a nil solution and no error if the finish cannot be reached from any start cell.
+//
+// The search may be limited by the search options; it is aborted with an AbortedError
+// carrying the best partial race found so far once any limit is exceeded or the context is done.
+// The statistics of the search are reported with the WithStats option.
 type Pathfinder interface {
-	FindPath(start, finish *Cell) ([]*Cell, error)
+	FindPath(ctx context.Context, starts []*Cell, finish Goal, opts ...SearchOption) (*Solution, error)
+}
+
+// Algorithm is the name of a path finding algorithm.
+type Algorithm string
+
+const (
+	// AlgorithmAStar is the A* algorithm implemented by GridPathfinder.
+	AlgorithmAStar Algorithm = "astar"
+	// AlgorithmBFS is the breadth-first search implemented by BreadthFirstPathfinder.
+	AlgorithmBFS Algorithm = "bfs"
+	// AlgorithmIDAStar is the iterative deepening A* algorithm implemented by IterativeDeepeningPathfinder.
+	AlgorithmIDAStar Algorithm = "idastar"
+	// AlgorithmBidirectional is the bidirectional A* algorithm implemented by BidirectionalPathfinder.
+	AlgorithmBidirectional Algorithm = "bidirectional"
+)
+
+// ParseAlgorithm returns the algorithm with the given name.
+//
+// An empty name stands for the default algorithm, which is A*.
+func ParseAlgorithm(name string) (Algorithm, error) {
+	switch alg := Algorithm(name); alg {
+	case "":
+		return AlgorithmAStar, nil
+	case AlgorithmAStar, AlgorithmBFS, AlgorithmIDAStar, AlgorithmBidirectional:
+		return alg, nil
+	default:
+		return "", errors.Errorf("unknown path finding algorithm %q", name)
+	}
 }
 
-// Heuristic is a function that estimates the cost of moving from cell a to cell b.
-type Heuristic func(a, b *Cell) int
+// Heuristic is a function that estimates the cost of moving from cell a to cell b
+// under the given game rules.
+type Heuristic func(a, b *Cell, r Rules) int
 
 // GridPathfinder finds the shortest path between to cells in a given grid.
+//
+// The pathfinder keeps no state between searches, so it can be used
+// for any number of FindPath calls, including concurrent ones.
 type GridPathfinder struct {
 	Grid      *Grid
 	Heuristic Heuristic
+	Rules     Rules
 }
 
-// NewGridPathfinder returns a new grid pathfinder with the given grid and heuristic function.
-func NewGridPathfinder(grid *Grid, h Heuristic) Pathfinder {
+// NewGridPathfinder returns a new grid pathfinder with the given grid, heuristic function and game rules.
+func NewGridPathfinder(grid *Grid, h Heuristic, rules Rules) Pathfinder {
 	if grid == nil || h == nil {
 		return nil
 	}
@@ -29,81 +70,117 @@ func NewGridPathfinder(grid *Grid, h Heuristic) Pathfinder {
 	return &GridPathfinder{
 		Grid:      grid,
 		Heuristic: h,
+		Rules:     rules,
 	}
 }
 
-// FindPath returns the shortest path from the start cell to the end cell.
+// FindPath returns the shortest path from any of the start cells to the finish.
 //
-// The path is calculated using the A* algorithm.
-func (pf *GridPathfinder) FindPath(start, finish *Cell) ([]*Cell, error) {
-	if start == nil || finish == nil {
-		return nil, errors.New("start and finish cells must be provided")
+// The path is calculated using the A* algorithm over the states of the hopper
+// (i.e., a cell together with the velocity the hopper has reached it with),
+// so the same cell may be visited several times with different velocities.
+// All the start cells are searched at once, so the best race over all the start
+// and finish cells is found in a single search.
+//
+// The cost of a race is the sum of the costs of landing on the cells of its hops,
+// which is the number of hops unless the grid has terrain. The heuristic estimate of the number of hops
+// is multiplied by the minimal landing cost of the grid, so it never overestimates the cost of the hops left.
+//
+// The partial race of an aborted search leads to the discovered state with the lowest heuristic estimate.
+func (pf *GridPathfinder) FindPath(ctx context.Context, starts []*Cell, finish Goal, opts ...SearchOption) (*Solution, error) {
+	initials, err := getEndpoints(pf.Grid, starts, finish)
+	if err != nil {
+		return nil, err
 	}
 
-	// get start point
-	s := pf.Grid.GetCell(start.X, start.Y)
-	if s == nil {
-		return nil, errors.New("start cell is out of grid")
-	}
+	return pf.search(ctx, initials, finish, opts...)
+}
 
-	// get finish point
-	f := pf.Grid.GetCell(finish.X, finish.Y)
-	if f == nil {
-		return nil, errors.New("finish cell is out of grid")
-	}
-	if !f.Available {
-		return nil, errors.New("finish cell is not available")
-	}
+// search returns the cheapest race from any of the initial states to the finish using the A* algorithm.
+func (pf *GridPathfinder) search(ctx context.Context, initials []*Cell, finish Goal, opts ...SearchOption) (*Solution, error) {
+	settings := newSearchSettings(opts)
+
+	var stats SearchStats
+	defer settings.report(&stats)
 
-	// initialize the start cell
-	s.GCost = 0
-	s.HCost = pf.Heuristic(s, f)
-	s.FCost = s.GCost + s.HCost
-	s.Speed = Velocity{X: 0, Y: 0}
+	// nodes holds the search bookkeeping of every state discovered so far
+	nodes := make(map[State]*node, len(initials))
 
-	// initialize the open cells priority queue
+	// initialize the open states priority queue
 	open := &priorityQueue{}
 	heap.Init(open)
-	heap.Push(open, s)
+
+	// initialize the start states
+	for _, initial := range initials {
+		if _, ok := nodes[initial.State()]; ok {
+			continue
+		}
+
+		n := newNode(initial, nil)
+		n.hCost = pf.estimate(initial, finish)
+		n.fCost = n.gCost + n.hCost
+		n.open = true
+
+		nodes[n.state()] = n
+		heap.Push(open, n)
+		stats.Generated++
+		stats.open(open.Len())
+	}
 
 	for open.Len() > 0 {
-		// get the cell with the lowest priority (e.g., the cell with the lowest FCost)
+		if err := settings.check(ctx, stats.Expanded, open.Len()); err != nil {
+			return nil, abort(err, pf.Grid, nodes, pf.Heuristic, finish, pf.Rules)
+		}
+
+		// get the state with the lowest priority (e.g., the state with the lowest fCost)
 		// and mark it as closed
-		current := heap.Pop(open).(*Cell)
-		current.Open = false
-		current.Closed = true
+		current := heap.Pop(open).(*node)
+		current.open = false
+		current.closed = true
 
-		// if the finish cell is reached, reconstruct the path and return it
-		if current == f {
-			return reconstructPath(current), nil
+		// if the race is finished, reconstruct the path and return it
+		if at, ok := pf.Rules.FinishesAt(current.cell, finish); ok {
+			return newSolution(pf.Grid, current.path(), at), nil
 		}
 
-		// calculate the cost of moving to the neighbors of the current cell;
-		// in the case of Hopping Race game, the cost is the number of hops,
-		// and it remains the same for all neighbors
-		gCost := current.GCost + 1
-
-		// evaluate neighbors of the current cell and push them to the open cells priority queue
-		for _, neighbor := range pf.Grid.GetNeighbors(current) {
-			// prepare the neighbor cell for re-evaluation
-			// if a new path to it is shorter than the previous one
-			if gCost <= neighbor.GCost {
-				if neighbor.Open {
-					heap.Remove(open, open.GetIndex(neighbor))
+		stats.Expanded++
+
+		// evaluate neighbors of the current state and push them to the open states priority queue
+		for _, next := range pf.Grid.GetNeighbors(current.cell, pf.Rules) {
+			// calculate the cost of moving to the neighbor;
+			// in the case of Hopping Race game, each hop costs the cost of landing on its cell,
+			// which is the same for all cells unless the grid has terrain
+			gCost := current.gCost + pf.Grid.LandingCost(next.X, next.Y)
+
+			neighbor, ok := nodes[next.State()]
+			if !ok {
+				neighbor = newNode(next, current)
+				nodes[neighbor.state()] = neighbor
+				stats.Generated++
+			} else if gCost < neighbor.gCost {
+				// re-evaluate the known state if a new path to it is shorter than the previous one:
+				// an open state is moved up the queue in place (its hCost stays the same),
+				// while a closed state is reopened
+				if neighbor.open {
+					neighbor.gCost = gCost
+					neighbor.fCost = neighbor.gCost + neighbor.hCost
+					neighbor.parent = current
+					heap.Fix(open, open.GetIndex(neighbor))
+					continue
 				}
-				neighbor.Open = false
-				neighbor.Closed = false
+				neighbor.closed = false
+				stats.Reopened++
 			}
 
-			// evaluate not visited cell
-			if !neighbor.Open && !neighbor.Closed {
-				neighbor.GCost = gCost
-				neighbor.HCost = pf.Heuristic(neighbor, f)
-				neighbor.FCost = neighbor.GCost + neighbor.HCost
-				neighbor.Speed = Velocity{X: neighbor.X - current.X, Y: neighbor.Y - current.Y}
-				neighbor.Parent = current
-				neighbor.Open = true
+			// evaluate not visited state
+			if !neighbor.open && !neighbor.closed {
+				neighbor.gCost = gCost
+				neighbor.hCost = pf.estimate(neighbor.cell, finish)
+				neighbor.fCost = neighbor.gCost + neighbor.hCost
+				neighbor.parent = current
+				neighbor.open = true
 				heap.Push(open, neighbor)
+				stats.open(open.Len())
 			}
 		}
 	}
@@ -112,16 +189,81 @@ func (pf *GridPathfinder) FindPath(start, finish *Cell) ([]*Cell, error) {
 	return nil, nil
 }
 
-// reconstructPath returns the path from the start cell to the given cell.
-func reconstructPath(cell *Cell) []*Cell {
-	path := make([]*Cell, 0)
+// getEndpoints returns the initial states of the hopper (i.e., resting on the start cells)
+// after checking both the start cells and the finish.
+//
+// An error is returned if the start cells or the finish are not provided,
+// if any of their cells is out of the grid, or if none of the finish cells is available.
+func getEndpoints(grid *Grid, starts []*Cell, finish Goal) ([]*Cell, error) {
+	if len(starts) == 0 || finish == nil || len(finish.Cells()) == 0 {
+		return nil, errors.New("start and finish cells must be provided")
+	}
+
+	// get start points
+	initials := make([]*Cell, 0, len(starts))
+	for _, start := range starts {
+		if start == nil {
+			return nil, errors.New("start and finish cells must be provided")
+		}
+
+		// the hopper rests on the start cell
+		s := grid.GetCell(start.X, start.Y)
+		if s == nil {
+			return nil, errors.New("start cell is out of grid")
+		}
+
+		initials = append(initials, s)
+	}
+
+	if err := checkFinish(grid, finish); err != nil {
+		return nil, err
+	}
+
+	return initials, nil
+}
 
-	for cell != nil {
-		path = append(path, cell)
-		cell = cell.Parent
+// checkFinish returns an error if any of the finish cells is out of the grid,
+// or if none of them is available.
+func checkFinish(grid *Grid, finish Goal) error {
+	available := false
+	for _, c := range finish.Cells() {
+		if !grid.contains(c.X, c.Y) {
+			return errors.New("finish cell is out of grid")
+		}
+
+		available = available || grid.IsAvailable(c.X, c.Y)
+	}
+	if !available {
+		return errors.New("finish cell is not available")
+	}
+
+	return nil
+}
+
+// estimate returns the heuristic estimate of the cost of the hops needed
+// to finish the race from the given cell.
+func (pf *GridPathfinder) estimate(cell *Cell, finish Goal) int {
+	return estimate(pf.Heuristic, cell, finish, pf.Rules) * pf.Grid.MinLandingCost()
+}
+
+// estimate returns the heuristic estimate of the number of hops needed
+// to finish the race from the given cell.
+//
+// The estimate is zero if the hopper has already finished the race,
+// otherwise it is the minimal estimate over all the finish cells.
+func estimate(h Heuristic, cell *Cell, finish Goal, rules Rules) int {
+	if rules.Finishes(cell, finish) {
+		return 0
+	}
+
+	best := -1
+	for _, f := range finish.Cells() {
+		if e := h(cell, f, rules); best < 0 || e < best {
+			best = e
+		}
 	}
 
-	return reversePath(path)
+	return max(best, 0)
 }
 
 // reversePath reverses the given path.
@@ -1,12 +1,69 @@
 package pathfinder
 
 import (
+	"context"
+	"math/rand"
+	"sync"
 	"testing"
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
+func TestParseAlgorithm(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want Algorithm
+		err  error
+	}{
+		{
+			name: "default algorithm",
+			in:   "",
+			want: AlgorithmAStar,
+		},
+		{
+			name: "A* algorithm",
+			in:   "astar",
+			want: AlgorithmAStar,
+		},
+		{
+			name: "breadth-first search",
+			in:   "bfs",
+			want: AlgorithmBFS,
+		},
+		{
+			name: "iterative deepening A*",
+			in:   "idastar",
+			want: AlgorithmIDAStar,
+		},
+		{
+			name: "bidirectional A*",
+			in:   "bidirectional",
+			want: AlgorithmBidirectional,
+		},
+		{
+			name: "unknown algorithm",
+			in:   "dfs",
+			want: "",
+			err:  errors.New("unknown path finding algorithm"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseAlgorithm(test.in)
+
+			if test.err != nil {
+				assert.ErrorContains(t, err, test.err.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
 func TestNewGridPathfinder(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -17,16 +74,16 @@ func TestNewGridPathfinder(t *testing.T) {
 		{
 			name:      "valid grid and heuristic",
 			grid:      NewGrid(3, 3),
-			heuristic: func(a, b *Cell) int { return 0 },
+			heuristic: func(a, b *Cell, _ Rules) int { return 0 },
 			want: &GridPathfinder{
 				Grid:      NewGrid(3, 3),
-				Heuristic: func(a, b *Cell) int { return 0 },
+				Heuristic: func(a, b *Cell, _ Rules) int { return 0 },
 			},
 		},
 		{
 			name:      "nil grid",
 			grid:      nil,
-			heuristic: func(a, b *Cell) int { return 0 },
+			heuristic: func(a, b *Cell, _ Rules) int { return 0 },
 			want:      nil,
 		},
 		{
@@ -38,7 +95,7 @@ func TestNewGridPathfinder(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			got := NewGridPathfinder(test.grid, test.heuristic)
+			got := NewGridPathfinder(test.grid, test.heuristic, Rules{})
 			if test.want != nil {
 				assert.NotNil(t, got)
 				assert.Equal(t, test.want.(*GridPathfinder).Grid, got.(*GridPathfinder).Grid)
@@ -53,9 +110,9 @@ func TestGridPathfinder_FindPath(t *testing.T) {
 	tests := []struct {
 		name   string
 		pf     *GridPathfinder
-		start  *Cell
-		finish *Cell
-		want   []*Cell
+		starts []*Cell
+		finish Goal
+		want   *Solution
 		err    error
 	}{
 		{
@@ -64,72 +121,141 @@ func TestGridPathfinder_FindPath(t *testing.T) {
 				Grid:      NewGrid(3, 3),
 				Heuristic: ChebyshevDistance,
 			},
-			start:  &Cell{X: 0, Y: 0},
-			finish: &Cell{X: 2, Y: 2},
-			want: []*Cell{
-				{
-					X:         0,
-					Y:         0,
-					Available: true,
-					GCost:     0,
-					HCost:     2,
-					FCost:     2,
-					Speed:     Velocity{X: 0, Y: 0},
-					Closed:    true,
-					Parent:    nil,
+			starts: []*Cell{{X: 0, Y: 0}},
+			finish: NewLandingGoal(&Cell{X: 2, Y: 2}),
+			want: &Solution{
+				Start: State{X: 0, Y: 0},
+				Hops: []Hop{
+					{X: 1, Y: 1, Speed: Velocity{X: 1, Y: 1}, Acceleration: Acceleration{X: 1, Y: 1}},
+					{X: 2, Y: 2, Speed: Velocity{X: 1, Y: 1}, Acceleration: Acceleration{X: 0, Y: 0}},
 				},
-				{
-					X:         1,
-					Y:         1,
-					Available: true,
-					GCost:     1,
-					HCost:     1,
-					FCost:     2,
-					Speed:     Velocity{X: 1, Y: 1},
-					Closed:    true,
-					Parent: &Cell{
-						X:         0,
-						Y:         0,
-						Available: true,
-						GCost:     0,
-						HCost:     2,
-						FCost:     2,
-						Speed:     Velocity{X: 0, Y: 0},
-						Closed:    true,
-						Parent:    nil,
-					},
+				Finish: Position{X: 2, Y: 2},
+				Cost:   2,
+			},
+			err: nil,
+		},
+		{
+			name: "slow arrival does not close the cell for faster ones",
+			pf: &GridPathfinder{
+				Grid: NewGrid(2, 7, []Obstacle{
+					{X1: 3, Y1: 0, X2: 3, Y2: 1},
+				}...),
+				Heuristic: ChebyshevDistance,
+			},
+			starts: []*Cell{{X: 4, Y: 1}},
+			finish: NewLandingGoal(&Cell{X: 2, Y: 1}),
+			want: &Solution{
+				Start: State{X: 4, Y: 1},
+				Hops: []Hop{
+					{X: 5, Y: 1, Speed: Velocity{X: 1, Y: 0}, Acceleration: Acceleration{X: 1, Y: 0}},
+					{X: 5, Y: 0, Speed: Velocity{X: 0, Y: -1}, Acceleration: Acceleration{X: -1, Y: -1}},
+					{X: 4, Y: 0, Speed: Velocity{X: -1, Y: 0}, Acceleration: Acceleration{X: -1, Y: 1}},
+					{X: 2, Y: 1, Speed: Velocity{X: -2, Y: 1}, Acceleration: Acceleration{X: -1, Y: 1}},
 				},
-				{
-					X:         2,
-					Y:         2,
-					Available: true,
-					GCost:     2,
-					HCost:     0,
-					FCost:     2,
-					Speed:     Velocity{X: 1, Y: 1},
-					Closed:    true,
-					Parent: &Cell{
-						X:         1,
-						Y:         1,
-						Available: true,
-						GCost:     1,
-						HCost:     1,
-						FCost:     2,
-						Speed:     Velocity{X: 1, Y: 1},
-						Closed:    true,
-						Parent: &Cell{
-							X:         0,
-							Y:         0,
-							Available: true,
-							GCost:     0,
-							HCost:     2,
-							FCost:     2,
-							Speed:     Velocity{X: 0, Y: 0},
-							Closed:    true,
-							Parent:    nil,
-						},
-					},
+				Finish: Position{X: 2, Y: 1},
+				Cost:   4,
+			},
+			err: nil,
+		},
+		{
+			name: "no path found flying over a wall",
+			pf: &GridPathfinder{
+				Grid: NewGrid(2, 7, []Obstacle{
+					{X1: 3, Y1: 0, X2: 3, Y2: 1},
+				}...),
+				Heuristic: VelocityDistance,
+				Rules:     Rules{FlightCollision: true},
+			},
+			starts: []*Cell{{X: 4, Y: 1}},
+			finish: NewLandingGoal(&Cell{X: 2, Y: 1}),
+			want:   nil,
+			err:    nil,
+		},
+		{
+			name: "hopper must come to rest at finish",
+			pf: &GridPathfinder{
+				Grid:      NewGrid(1, 8),
+				Heuristic: VelocityDistance,
+				Rules:     Rules{StopAtFinish: true},
+			},
+			starts: []*Cell{{X: 0, Y: 0}},
+			finish: NewLandingGoal(&Cell{X: 7, Y: 0}),
+			want: &Solution{
+				Start: State{X: 0, Y: 0},
+				Hops: []Hop{
+					{X: 1, Y: 0, Speed: Velocity{X: 1, Y: 0}, Acceleration: Acceleration{X: 1, Y: 0}},
+					{X: 3, Y: 0, Speed: Velocity{X: 2, Y: 0}, Acceleration: Acceleration{X: 1, Y: 0}},
+					{X: 5, Y: 0, Speed: Velocity{X: 2, Y: 0}, Acceleration: Acceleration{X: 0, Y: 0}},
+					{X: 6, Y: 0, Speed: Velocity{X: 1, Y: 0}, Acceleration: Acceleration{X: -1, Y: 0}},
+					{X: 7, Y: 0, Speed: Velocity{X: 1, Y: 0}, Acceleration: Acceleration{X: 0, Y: 0}},
+				},
+				Finish: Position{X: 7, Y: 0},
+				Cost:   5,
+			},
+			err: nil,
+		},
+		{
+			name: "finish line crossed over obstacles",
+			pf: &GridPathfinder{
+				Grid: NewGrid(5, 5, []Obstacle{
+					{X1: 2, Y1: 1, X2: 3, Y2: 2},
+					{X1: 2, Y1: 3, X2: 2, Y2: 4},
+				}...),
+				Heuristic: VelocityDistance,
+			},
+			starts: []*Cell{{X: 3, Y: 4}},
+			finish: NewFinishLine(1, 3, 3, 2),
+			want: &Solution{
+				Start: State{X: 3, Y: 4},
+				Hops: []Hop{
+					{X: 3, Y: 3, Speed: Velocity{X: 0, Y: -1}, Acceleration: Acceleration{X: 0, Y: -1}},
+					{X: 4, Y: 1, Speed: Velocity{X: 1, Y: -2}, Acceleration: Acceleration{X: 1, Y: -1}},
+				},
+				Finish: Position{X: 3, Y: 2},
+				Cost:   2,
+			},
+			err: nil,
+		},
+		{
+			name: "finish line landed on",
+			pf: &GridPathfinder{
+				Grid: NewGrid(5, 5, []Obstacle{
+					{X1: 2, Y1: 1, X2: 3, Y2: 2},
+					{X1: 2, Y1: 3, X2: 2, Y2: 4},
+				}...),
+				Heuristic: VelocityDistance,
+			},
+			starts: []*Cell{{X: 3, Y: 4}},
+			finish: NewLandingGoal(NewFinishLine(1, 3, 3, 2).Cells()...),
+			want: &Solution{
+				Start: State{X: 3, Y: 4},
+				Hops: []Hop{
+					{X: 4, Y: 4, Speed: Velocity{X: 1, Y: 0}, Acceleration: Acceleration{X: 1, Y: 0}},
+					{X: 4, Y: 3, Speed: Velocity{X: 0, Y: -1}, Acceleration: Acceleration{X: -1, Y: -1}},
+					{X: 3, Y: 3, Speed: Velocity{X: -1, Y: 0}, Acceleration: Acceleration{X: -1, Y: 1}},
+					{X: 1, Y: 3, Speed: Velocity{X: -2, Y: 0}, Acceleration: Acceleration{X: -1, Y: 0}},
+				},
+				Finish: Position{X: 1, Y: 3},
+				Cost:   4,
+			},
+			err: nil,
+		},
+		{
+			name: "best of several start and finish cells",
+			pf: &GridPathfinder{
+				Grid:      NewGrid(1, 12),
+				Heuristic: VelocityDistance,
+			},
+			starts: []*Cell{{X: 0, Y: 0}, {X: 11, Y: 0}},
+			finish: NewLandingGoal(&Cell{X: 4, Y: 0}, &Cell{X: 8, Y: 0}),
+			want: &Solution{
+				Start: State{X: 11, Y: 0},
+				Hops: []Hop{
+					{X: 10, Y: 0, Speed: Velocity{X: -1, Y: 0}, Acceleration: Acceleration{X: -1, Y: 0}},
+					{X: 8, Y: 0, Speed: Velocity{X: -2, Y: 0}, Acceleration: Acceleration{X: -1, Y: 0}},
 				},
+				Finish: Position{X: 8, Y: 0},
+				Cost:   2,
 			},
 			err: nil,
 		},
@@ -142,8 +268,8 @@ func TestGridPathfinder_FindPath(t *testing.T) {
 				}...),
 				Heuristic: ChebyshevDistance,
 			},
-			start:  &Cell{X: 0, Y: 0},
-			finish: &Cell{X: 2, Y: 2},
+			starts: []*Cell{{X: 0, Y: 0}},
+			finish: NewLandingGoal(&Cell{X: 2, Y: 2}),
 			want:   nil,
 			err:    nil,
 		},
@@ -153,8 +279,8 @@ func TestGridPathfinder_FindPath(t *testing.T) {
 				Grid:      NewGrid(3, 3),
 				Heuristic: ChebyshevDistance,
 			},
-			start:  nil,
-			finish: &Cell{X: 2, Y: 2},
+			starts: nil,
+			finish: NewLandingGoal(&Cell{X: 2, Y: 2}),
 			want:   nil,
 			err:    errors.New("start and finish cells must be provided"),
 		},
@@ -164,8 +290,19 @@ func TestGridPathfinder_FindPath(t *testing.T) {
 				Grid:      NewGrid(3, 3),
 				Heuristic: ChebyshevDistance,
 			},
-			start:  &Cell{X: 3, Y: 3},
-			finish: &Cell{X: 2, Y: 2},
+			starts: []*Cell{{X: 3, Y: 3}},
+			finish: NewLandingGoal(&Cell{X: 2, Y: 2}),
+			want:   nil,
+			err:    errors.New("start cell is out of grid"),
+		},
+		{
+			name: "one of start cells out of grid",
+			pf: &GridPathfinder{
+				Grid:      NewGrid(3, 3),
+				Heuristic: ChebyshevDistance,
+			},
+			starts: []*Cell{{X: 0, Y: 0}, {X: 0, Y: 3}},
+			finish: NewLandingGoal(&Cell{X: 2, Y: 2}),
 			want:   nil,
 			err:    errors.New("start cell is out of grid"),
 		},
@@ -175,8 +312,8 @@ func TestGridPathfinder_FindPath(t *testing.T) {
 				Grid:      NewGrid(3, 3),
 				Heuristic: ChebyshevDistance,
 			},
-			start:  &Cell{X: 0, Y: 0},
-			finish: &Cell{X: 3, Y: 3},
+			starts: []*Cell{{X: 0, Y: 0}},
+			finish: NewLandingGoal(&Cell{X: 3, Y: 3}),
 			want:   nil,
 			err:    errors.New("finish cell is out of grid"),
 		},
@@ -188,15 +325,15 @@ func TestGridPathfinder_FindPath(t *testing.T) {
 				}...),
 				Heuristic: ChebyshevDistance,
 			},
-			start:  &Cell{X: 0, Y: 0},
-			finish: &Cell{X: 2, Y: 2},
+			starts: []*Cell{{X: 0, Y: 0}},
+			finish: NewLandingGoal(&Cell{X: 2, Y: 2}),
 			want:   nil,
 			err:    errors.New("finish cell is not available"),
 		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			got, err := test.pf.FindPath(test.start, test.finish)
+			got, err := test.pf.FindPath(context.Background(), test.starts, test.finish)
 
 			if test.err != nil {
 				assert.Error(t, err)
@@ -209,3 +346,47 @@ func TestGridPathfinder_FindPath(t *testing.T) {
 		})
 	}
 }
+
+func TestGridPathfinder_FindPath_Concurrent(t *testing.T) {
+	grid := NewGrid(20, 20, []Obstacle{
+		{X1: 5, Y1: 0, X2: 6, Y2: 15},
+		{X1: 12, Y1: 4, X2: 13, Y2: 19},
+	}...)
+	pf := NewGridPathfinder(grid, VelocityDistance, Rules{})
+
+	// pick random queries on the same grid and solve them one by one
+	rnd := rand.New(rand.NewSource(1))
+
+	type query struct {
+		start, finish *Cell
+		want          int
+	}
+
+	queries := make([]query, 100)
+	for i := range queries {
+		start := randomCells(rnd, grid, 1)
+		finish := randomCells(rnd, grid, 1)
+		for len(start) == 0 || len(finish) == 0 {
+			start, finish = randomCells(rnd, grid, 1), randomCells(rnd, grid, 1)
+		}
+
+		solution, err := pf.FindPath(context.Background(), start, NewLandingGoal(finish...))
+		assert.NoError(t, err)
+
+		queries[i] = query{start: start[0], finish: finish[0], want: solution.Len()}
+	}
+
+	// solve the same queries at once with the same grid and pathfinder
+	wg := sync.WaitGroup{}
+	for _, q := range queries {
+		wg.Add(1)
+		go func(q query) {
+			defer wg.Done()
+
+			solution, err := pf.FindPath(context.Background(), []*Cell{q.start}, NewLandingGoal(q.finish))
+			assert.NoError(t, err)
+			assert.Equal(t, q.want, solution.Len(), "start %v, finish %v", q.start, q.finish)
+		}(q)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,306 @@
+package pathfinder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// The binary representation of a policy opens with the magic followed by its format version.
+const (
+	policyMagic   = "HRTP"
+	policyVersion = 1
+)
+
+// maxPolicySpeed is the maximal speed a policy covers: the accelerations between the speeds in range
+// must fit into a byte.
+const maxPolicySpeed = math.MaxInt8 / 2
+
+// maxPolicyStates is the maximal number of states a policy holds,
+// which keeps the memory taken by its table under half a gigabyte.
+const maxPolicyStates = 1 << 26
+
+// Policy holds the optimal next hop from every state of the hopper towards a fixed finish:
+// the minimal number of hops left to finish the race together with the acceleration of the first of them.
+//
+// A policy is calculated once for a track, so the hopper (e.g., an AI opponent) follows the optimal race
+// from any state it has got into without running a search. It can be stored with MarshalBinary
+// and loaded with UnmarshalBinary.
+type Policy struct {
+	// Rows is the number of rows in the grid.
+	Rows int
+	// Cols is the number of columns in the grid.
+	Cols int
+	// MaxSpeed is the maximal speed of the hopper along each axis the policy covers the velocities up to.
+	MaxSpeed int
+
+	// hops holds the minimal number of hops left per state (see index),
+	// or unreachable if the race cannot be finished from the state.
+	hops []int32
+	// moves holds the acceleration of the first hop of an optimal race per state.
+	moves []move
+}
+
+// move is the acceleration of a hop stored compactly.
+type move struct {
+	X, Y int8
+}
+
+// NewPolicy returns the policy of the grid towards the finish under the given game rules.
+//
+// The policy is calculated by the breadth-first search from the states finishing the race
+// over the hops reversed: the hopper lands on cell (x, y) with velocity v by the hop from cell (x - v.X, y - v.Y),
+// which it has left with any velocity the allowed acceleration turns into v.
+// So each state gets the number of hops of the first state found to be reached from it by a single hop, plus one,
+// and the acceleration of that hop. The states finishing the race (including the hopper resting on the finish)
+// take no more hops.
+//
+// The races are optimal by the number of hops, so the terrain of the grid is ignored.
+// The timed obstacles make the races depend on the time, so a grid with them has no policy.
+// The policy holds every state of the grid, so the sparse grids and the grids with more than maxPolicyStates states
+// are not supported.
+func NewPolicy(grid *Grid, finish Goal, rules Rules) (*Policy, error) {
+	if grid == nil {
+		return nil, errors.New("grid must be provided")
+	}
+	if finish == nil || len(finish.Cells()) == 0 {
+		return nil, errors.New("finish cells must be provided")
+	}
+	if err := checkFinish(grid, finish); err != nil {
+		return nil, err
+	}
+	if grid.obstacles != nil {
+		return nil, errors.New("policy does not support sparse grids")
+	}
+	if grid.Timed() {
+		return nil, errors.New("policy does not support timed obstacles")
+	}
+
+	maxSpeed := rules.GetMaxSpeed()
+	if maxSpeed > maxPolicySpeed {
+		return nil, errors.Errorf("policy does not support max speed over %d", maxPolicySpeed)
+	}
+	side := 2*maxSpeed + 1
+	if states := grid.Rows * grid.Cols * side * side; states > maxPolicyStates {
+		return nil, errors.Errorf("policy of %d states exceeds the limit of %d states", states, maxPolicyStates)
+	}
+
+	p := newPolicy(grid.Rows, grid.Cols, maxSpeed)
+
+	// the states finishing the race take no more hops
+	var queue []int
+	finished := func(x, y int, speed Velocity) {
+		if i := p.index(x, y, speed); p.hops[i] == unreachable {
+			p.hops[i] = 0
+			queue = append(queue, i)
+		}
+	}
+	for _, c := range finish.Cells() {
+		if rules.Finishes(&Cell{X: c.X, Y: c.Y}, finish) {
+			finished(c.X, c.Y, Velocity{})
+		}
+	}
+	for _, c := range finishingStates(grid, finish, rules) {
+		finished(c.X, c.Y, c.Speed)
+	}
+
+	accelerations := rules.Accelerations()
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		x, y, speed := p.state(current)
+
+		// the hopper resting on a cell has not hopped yet
+		if speed.X == 0 && speed.Y == 0 {
+			continue
+		}
+
+		// the hop to the current state is made from the cell behind it
+		fromX, fromY := x-speed.X, y-speed.Y
+		if !grid.contains(fromX, fromY) {
+			continue
+		}
+		if rules.FlightCollision && !clearFlight(fromX, fromY, x, y, grid.IsAvailable) {
+			continue
+		}
+		landed := grid.IsAvailable(fromX, fromY)
+
+		for _, a := range accelerations {
+			prev := Velocity{X: speed.X - a.X, Y: speed.Y - a.Y}
+
+			// skip the speed out of the allowed range
+			if prev.X < -maxSpeed || prev.X > maxSpeed || prev.Y < -maxSpeed || prev.Y > maxSpeed {
+				continue
+			}
+
+			// the hopper moving has landed on the cell, while the one resting may stand anywhere
+			if !landed && (prev.X != 0 || prev.Y != 0) {
+				continue
+			}
+
+			// the first state found leading to the state has the minimal number of hops
+			if i := p.index(fromX, fromY, prev); p.hops[i] == unreachable {
+				p.hops[i] = p.hops[current] + 1
+				p.moves[i] = move{X: int8(a.X), Y: int8(a.Y)}
+				queue = append(queue, i)
+			}
+		}
+	}
+
+	return p, nil
+}
+
+// newPolicy returns a new policy of a grid of the given size with no race finished from any state.
+func newPolicy(rows, cols, maxSpeed int) *Policy {
+	p := &Policy{
+		Rows:     rows,
+		Cols:     cols,
+		MaxSpeed: maxSpeed,
+	}
+
+	side := 2*maxSpeed + 1
+	p.hops = make([]int32, rows*cols*side*side)
+	p.moves = make([]move, len(p.hops))
+	for i := range p.hops {
+		p.hops[i] = unreachable
+	}
+
+	return p
+}
+
+// Lookup returns the minimal number of hops needed to finish the race from the state of the hopper
+// landed on the cell with the given coordinates with the given velocity,
+// and the acceleration of the first hop of such a race (none, if the race is finished at the state).
+//
+// It returns false if the race cannot be finished from the state,
+// or if the state is out of the grid or its velocity is out of the range of the policy.
+func (p *Policy) Lookup(x, y int, speed Velocity) (Acceleration, int, bool) {
+	if x < 0 || x >= p.Cols || y < 0 || y >= p.Rows ||
+		speed.X < -p.MaxSpeed || speed.X > p.MaxSpeed || speed.Y < -p.MaxSpeed || speed.Y > p.MaxSpeed {
+		return Acceleration{}, 0, false
+	}
+
+	i := p.index(x, y, speed)
+	if p.hops[i] == unreachable {
+		return Acceleration{}, 0, false
+	}
+
+	return Acceleration{X: int(p.moves[i].X), Y: int(p.moves[i].Y)}, int(p.hops[i]), true
+}
+
+// index returns the index of the state in the policy: the states are ordered by the cells row by row,
+// and the states of a cell are ordered by their velocities along the Y axis, then along the X axis.
+func (p *Policy) index(x, y int, speed Velocity) int {
+	side := 2*p.MaxSpeed + 1
+	return ((y*p.Cols+x)*side+speed.Y+p.MaxSpeed)*side + speed.X + p.MaxSpeed
+}
+
+// state returns the coordinates of the cell and the velocity of the state with the given index.
+func (p *Policy) state(i int) (int, int, Velocity) {
+	side := 2*p.MaxSpeed + 1
+
+	vx := i%side - p.MaxSpeed
+	i /= side
+	vy := i%side - p.MaxSpeed
+	i /= side
+
+	return i % p.Cols, i / p.Cols, Velocity{X: vx, Y: vy}
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+//
+// The policy is encoded as the magic "HRTP" and the format version followed by the size of the grid
+// and the maximal speed as unsigned varints, and the states in the order of their indexes:
+// each state holds the number of hops left plus one as an unsigned varint (zero if the race cannot be finished),
+// followed by the acceleration of the first hop along the X and Y axes as signed bytes, if any hop is left.
+func (p *Policy) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 0, len(policyMagic)+1+3*binary.MaxVarintLen64+2*len(p.hops))
+
+	buf = append(buf, policyMagic...)
+	buf = append(buf, policyVersion)
+	buf = binary.AppendUvarint(buf, uint64(p.Rows))
+	buf = binary.AppendUvarint(buf, uint64(p.Cols))
+	buf = binary.AppendUvarint(buf, uint64(p.MaxSpeed))
+
+	for i, hops := range p.hops {
+		buf = binary.AppendUvarint(buf, uint64(hops+1))
+		if hops > 0 {
+			buf = append(buf, byte(p.moves[i].X), byte(p.moves[i].Y))
+		}
+	}
+
+	return buf, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+//
+// An error is returned if the data is not a policy encoded by MarshalBinary.
+func (p *Policy) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+
+	magic := make([]byte, len(policyMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != policyMagic {
+		return errors.New("data is not a policy")
+	}
+
+	version, err := r.ReadByte()
+	if err != nil {
+		return errors.New("data is not a policy")
+	}
+	if version != policyVersion {
+		return errors.Errorf("unsupported policy version %d", version)
+	}
+
+	var size [3]uint64
+	for i := range size {
+		if size[i], err = binary.ReadUvarint(r); err != nil {
+			return errors.Wrap(err, "failed to read policy size")
+		}
+	}
+	rows, cols, maxSpeed := size[0], size[1], size[2]
+	if maxSpeed > maxPolicySpeed {
+		return errors.Errorf("invalid policy max speed %d", maxSpeed)
+	}
+
+	// every state takes a byte at least, so the size is checked against the data before the states are allocated
+	side, states := 2*maxSpeed+1, uint64(1)
+	for _, n := range []uint64{rows, cols, side, side} {
+		if n == 0 || n > uint64(r.Len()) || states*n > uint64(r.Len()) {
+			return errors.Errorf("invalid policy size %dx%d", rows, cols)
+		}
+		states *= n
+	}
+
+	decoded := newPolicy(int(rows), int(cols), int(maxSpeed))
+	for i := range decoded.hops {
+		hops, err := binary.ReadUvarint(r)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read policy state %d", i)
+		}
+		if hops > math.MaxInt32 {
+			return errors.Errorf("invalid number of hops of policy state %d", i)
+		}
+		decoded.hops[i] = int32(hops) - 1
+
+		if decoded.hops[i] > 0 {
+			var m [2]byte
+			if _, err := io.ReadFull(r, m[:]); err != nil {
+				return errors.Wrapf(err, "failed to read policy state %d", i)
+			}
+			decoded.moves[i] = move{X: int8(m[0]), Y: int8(m[1])}
+		}
+	}
+
+	if r.Len() > 0 {
+		return errors.New("unexpected data after policy states")
+	}
+
+	*p = *decoded
+
+	return nil
+}
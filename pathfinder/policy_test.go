@@ -0,0 +1,347 @@
+package pathfinder
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		grid    *Grid
+		finish  Goal
+		rules   Rules
+		wantErr string
+	}{
+		{
+			name:   "valid policy",
+			grid:   NewGrid(3, 3),
+			finish: NewLandingGoal(&Cell{X: 2, Y: 2}),
+		},
+		{
+			name:    "no grid",
+			finish:  NewLandingGoal(&Cell{X: 2, Y: 2}),
+			wantErr: "grid must be provided",
+		},
+		{
+			name:    "no finish",
+			grid:    NewGrid(3, 3),
+			finish:  NewLandingGoal(),
+			wantErr: "finish cells must be provided",
+		},
+		{
+			name:    "finish cell out of grid",
+			grid:    NewGrid(3, 3),
+			finish:  NewLandingGoal(&Cell{X: 3, Y: 2}),
+			wantErr: "finish cell is out of grid",
+		},
+		{
+			name:    "finish cell not available",
+			grid:    NewGrid(3, 3, Obstacle{X1: 2, Y1: 2, X2: 2, Y2: 2}),
+			finish:  NewLandingGoal(&Cell{X: 2, Y: 2}),
+			wantErr: "finish cell is not available",
+		},
+		{
+			name:    "sparse grid",
+			grid:    newGrid(3, 3, true),
+			finish:  NewLandingGoal(&Cell{X: 2, Y: 2}),
+			wantErr: "policy does not support sparse grids",
+		},
+		{
+			name:    "too many states",
+			grid:    NewGrid(1000, 1000),
+			finish:  NewLandingGoal(&Cell{X: 2, Y: 2}),
+			rules:   Rules{MaxSpeed: 5},
+			wantErr: "policy of 121000000 states exceeds the limit of 67108864 states",
+		},
+		{
+			name:    "timed obstacles",
+			grid:    NewGrid(3, 3, Obstacle{X1: 1, Y1: 1, X2: 1, Y2: 1, ActiveFrom: 2}),
+			finish:  NewLandingGoal(&Cell{X: 2, Y: 2}),
+			wantErr: "policy does not support timed obstacles",
+		},
+		{
+			name:    "max speed too high",
+			grid:    NewGrid(3, 3),
+			finish:  NewLandingGoal(&Cell{X: 2, Y: 2}),
+			rules:   Rules{MaxSpeed: 64},
+			wantErr: "policy does not support max speed over 63",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := NewPolicy(test.grid, test.finish, test.rules)
+			if test.wantErr != "" {
+				assert.ErrorContains(t, err, test.wantErr)
+				assert.Nil(t, got)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.grid.Rows, got.Rows)
+			assert.Equal(t, test.grid.Cols, got.Cols)
+			assert.Equal(t, test.rules.GetMaxSpeed(), got.MaxSpeed)
+		})
+	}
+}
+
+func TestPolicy_Lookup(t *testing.T) {
+	policy, err := NewPolicy(NewGrid(1, 10), NewLandingGoal(&Cell{X: 9, Y: 0}), Rules{})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name             string
+		x, y             int
+		speed            Velocity
+		wantAcceleration Acceleration
+		wantHops         int
+		wantOk           bool
+	}{
+		{
+			name:             "resting on the start",
+			x:                0,
+			speed:            Velocity{},
+			wantAcceleration: Acceleration{X: 1},
+			wantHops:         4,
+			wantOk:           true,
+		},
+		{
+			name:             "going at full speed",
+			x:                3,
+			speed:            Velocity{X: 3},
+			wantAcceleration: Acceleration{X: 0},
+			wantHops:         2,
+			wantOk:           true,
+		},
+		{
+			// the hopper never stops on the way, so it cannot turn back on a single row
+			name:  "going backward",
+			x:     5,
+			speed: Velocity{X: -1},
+		},
+		{
+			name:   "finished",
+			x:      9,
+			speed:  Velocity{X: 2},
+			wantOk: true,
+		},
+		{
+			name:  "too fast to finish",
+			x:     8,
+			speed: Velocity{X: 3},
+		},
+		{
+			name:  "out of grid",
+			x:     10,
+			speed: Velocity{},
+		},
+		{
+			name:  "speed out of range",
+			x:     0,
+			speed: Velocity{X: 4},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			acceleration, hops, ok := policy.Lookup(test.x, test.y, test.speed)
+			assert.Equal(t, test.wantOk, ok)
+			assert.Equal(t, test.wantHops, hops)
+			assert.Equal(t, test.wantAcceleration, acceleration)
+		})
+	}
+}
+
+// TestPolicy_Lookup_Reference checks the policy of random tracks state by state:
+// the finishing states take no hops, each other state takes a hop more than the best state it can hop to
+// (the one its acceleration leads to), and the races from the resting states take as many hops as the breadth-first search finds.
+func TestPolicy_Lookup_Reference(t *testing.T) {
+	rules := map[string]Rules{
+		"classic rules":    {},
+		"fast hoppers":     {MaxSpeed: 5, MaxAcceleration: 2},
+		"stop at finish":   {StopAtFinish: true},
+		"flight collision": {FlightCollision: true},
+	}
+
+	for rName, r := range rules {
+		t.Run(rName, func(t *testing.T) {
+			rnd := rand.New(rand.NewSource(1))
+
+			for i := 0; i < 50; i++ {
+				grid, _, finish := randomTrack(rnd, 8, 8)
+
+				var goal Goal = NewLandingGoal(finish...)
+				if rnd.Intn(3) == 0 {
+					goal = NewFinishLine(finish[0].X, 0, finish[0].X, grid.Rows-1)
+				}
+
+				policy, err := NewPolicy(grid, goal, r)
+				require.NoError(t, err)
+
+				maxSpeed := r.GetMaxSpeed()
+				for y := 0; y < grid.Rows; y++ {
+					for x := 0; x < grid.Cols; x++ {
+						for vy := -maxSpeed; vy <= maxSpeed; vy++ {
+							for vx := -maxSpeed; vx <= maxSpeed; vx++ {
+								cell := &Cell{X: x, Y: y, Available: grid.IsAvailable(x, y), Speed: Velocity{X: vx, Y: vy}}
+								checkPolicyState(t, policy, grid, goal, r, cell)
+							}
+						}
+
+						want, err := NewBreadthFirstPathfinder(grid, r).FindPath(context.Background(), []*Cell{{X: x, Y: y}}, goal)
+						require.NoError(t, err)
+
+						_, hops, ok := policy.Lookup(x, y, Velocity{})
+						assert.Equal(t, want != nil, ok, "start (%d,%d), finish %v", x, y, goal.Cells())
+						if want != nil {
+							assert.Equal(t, len(want.Hops), hops, "start (%d,%d), finish %v", x, y, goal.Cells())
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+// checkPolicyState checks the policy of a single state against the states the hopper can hop to from it.
+func checkPolicyState(t *testing.T, policy *Policy, grid *Grid, finish Goal, rules Rules, cell *Cell) {
+	t.Helper()
+
+	acceleration, hops, ok := policy.Lookup(cell.X, cell.Y, cell.Speed)
+
+	// the hopper moving has landed on an available cell
+	if !cell.Available && cell.Speed != (Velocity{}) {
+		assert.False(t, ok, "state %v", cell.State())
+		return
+	}
+
+	if rules.Finishes(cell, finish) {
+		assert.True(t, ok, "state %v", cell.State())
+		assert.Zero(t, hops, "state %v", cell.State())
+		return
+	}
+
+	best := -1
+	var next *Cell
+	for _, n := range grid.GetNeighbors(cell, rules) {
+		if _, h, ok := policy.Lookup(n.X, n.Y, n.Speed); ok && (best < 0 || h < best) {
+			best = h
+		}
+
+		if n.Speed.X-cell.Speed.X == acceleration.X && n.Speed.Y-cell.Speed.Y == acceleration.Y {
+			next = n
+		}
+	}
+
+	assert.Equal(t, best >= 0, ok, "state %v", cell.State())
+	if !ok || best < 0 {
+		return
+	}
+
+	assert.Equal(t, best+1, hops, "state %v", cell.State())
+	if assert.NotNil(t, next, "state %v", cell.State()) {
+		_, nextHops, _ := policy.Lookup(next.X, next.Y, next.Speed)
+		assert.Equal(t, hops-1, nextHops, "state %v", cell.State())
+	}
+}
+
+func TestPolicy_MarshalBinary(t *testing.T) {
+	grid := NewGrid(10, 12, Obstacle{X1: 3, X2: 8, Y1: 4, Y2: 5})
+	policy, err := NewPolicy(grid, NewFinishLine(11, 0, 11, 9), Rules{MaxSpeed: 4})
+	require.NoError(t, err)
+
+	data, err := policy.MarshalBinary()
+	require.NoError(t, err)
+
+	got := &Policy{}
+	require.NoError(t, got.UnmarshalBinary(data))
+	assert.Equal(t, policy, got)
+
+	// the unreachable states take a byte, and the others on a small track take three bytes
+	assert.Less(t, len(data), 3*len(policy.hops))
+}
+
+func TestPolicy_UnmarshalBinary(t *testing.T) {
+	policy, err := NewPolicy(NewGrid(2, 3), NewLandingGoal(&Cell{X: 2, Y: 1}), Rules{})
+	require.NoError(t, err)
+
+	data, err := policy.MarshalBinary()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{
+			name:    "no data",
+			data:    nil,
+			wantErr: "data is not a policy",
+		},
+		{
+			name:    "not a policy",
+			data:    []byte("HRTX\x01\x02\x03\x03"),
+			wantErr: "data is not a policy",
+		},
+		{
+			name:    "unsupported version",
+			data:    append([]byte("HRTP\x02"), data[5:]...),
+			wantErr: "unsupported policy version 2",
+		},
+		{
+			name:    "no size",
+			data:    data[:6],
+			wantErr: "failed to read policy size",
+		},
+		{
+			name:    "size exceeding data",
+			data:    append([]byte("HRTP\x01\x64\x64\x03"), data[8:]...),
+			wantErr: "invalid policy size 100x100",
+		},
+		{
+			name:    "max speed too high",
+			data:    []byte("HRTP\x01\x02\x03\x40"),
+			wantErr: "invalid policy max speed 64",
+		},
+		{
+			name:    "truncated states",
+			data:    data[:len(data)-1],
+			wantErr: "failed to read policy state",
+		},
+		{
+			name:    "trailing data",
+			data:    append(append([]byte{}, data...), 0),
+			wantErr: "unexpected data after policy states",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := &Policy{}
+			err := got.UnmarshalBinary(test.data)
+			assert.ErrorContains(t, err, test.wantErr)
+
+			// the policy is not changed by the data failed to decode
+			assert.Equal(t, &Policy{}, got)
+		})
+	}
+}
+
+func BenchmarkNewPolicy(b *testing.B) {
+	for _, size := range []int{30, 100} {
+		grid := NewGrid(size, size, benchmarkObstacles(size)...)
+		finish := NewLandingGoal(&Cell{X: size - 1, Y: size - 1})
+
+		b.Run(fmt.Sprintf("%dx%d", size, size), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := NewPolicy(grid, finish, Rules{FlightCollision: true}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -1,12 +1,16 @@
 package pathfinder
 
 // priorityQueue implements heap.Interface
-// to hold grid cells ordered by their costs descending.
+// to hold search nodes ordered by their costs descending.
+//
+// Each node in the queue refers to its entry, which keeps track of the node position in the heap,
+// so the position of a node is found in constant time
+// and the node can be re-evaluated in place with heap.Fix in logarithmic time.
 type priorityQueue []*queueEntry
 
-// queueEntry is a wrapper for a cell in the priority queue.
+// queueEntry is a wrapper for a node in the priority queue.
 type queueEntry struct {
-	cell  *Cell
+	node  *node
 	index int
 }
 
@@ -15,25 +19,25 @@ func (pq *priorityQueue) Len() int {
 	return len(*pq)
 }
 
-// Less reports whether the Cell under index i
-// is less than the Cell under index j.
-// The comparison is based on FCost of the cells
-// (in case of equality, comparison continues with HCost).
+// Less reports whether the node under index i
+// is less than the node under index j.
+// The comparison is based on fCost of the nodes
+// (in case of equality, comparison continues with hCost).
 func (pq *priorityQueue) Less(i, j int) bool {
 	if i < 0 || j < 0 || i >= len(*pq) || j >= len(*pq) {
 		return false
 	}
 
 	if (*pq)[i] == nil || (*pq)[j] == nil ||
-		(*pq)[i].cell == nil || (*pq)[j].cell == nil {
+		(*pq)[i].node == nil || (*pq)[j].node == nil {
 		return false
 	}
 
-	if (*pq)[i].cell.FCost == (*pq)[j].cell.FCost {
-		return (*pq)[i].cell.HCost < (*pq)[j].cell.HCost
+	if (*pq)[i].node.fCost == (*pq)[j].node.fCost {
+		return (*pq)[i].node.hCost < (*pq)[j].node.hCost
 	}
 
-	return (*pq)[i].cell.FCost < (*pq)[j].cell.FCost
+	return (*pq)[i].node.fCost < (*pq)[j].node.fCost
 }
 
 // Swap swaps the elements under indexes i and j.
@@ -53,15 +57,16 @@ func (pq *priorityQueue) Swap(i, j int) {
 
 // Push adds a new element to the queue.
 func (pq *priorityQueue) Push(x interface{}) {
-	n := len(*pq)
+	size := len(*pq)
 
-	cell, ok := x.(*Cell)
-	if !ok {
+	n, ok := x.(*node)
+	if !ok || n == nil {
 		return
 	}
 
-	entry := &queueEntry{cell: cell}
-	entry.index = n
+	entry := &queueEntry{node: n}
+	entry.index = size
+	n.entry = entry
 
 	*pq = append(*pq, entry)
 }
@@ -76,19 +81,24 @@ func (pq *priorityQueue) Pop() interface{} {
 	n := len(old)
 	entry := old[n-1]
 	entry.index = -1
+	entry.node.entry = nil
 	*pq = old[:n-1]
-	return entry.cell
+	return entry.node
 }
 
-// GetIndex returns the index of the given cell in the queue.
+// GetIndex returns the index of the given node in the queue.
 //
-// If the cell is not found, -1 is returned.
-func (pq *priorityQueue) GetIndex(cell *Cell) int {
-	for _, entry := range *pq {
-		if entry.cell == cell {
-			return entry.index
-		}
+// If the node is not found, -1 is returned.
+func (pq *priorityQueue) GetIndex(n *node) int {
+	if n == nil || n.entry == nil {
+		return -1
+	}
+
+	// the node may be held by another queue
+	i := n.entry.index
+	if i < 0 || i >= len(*pq) || (*pq)[i] != n.entry {
+		return -1
 	}
 
-	return -1
+	return i
 }
@@ -1,6 +1,8 @@
 package pathfinder
 
 import (
+	"container/heap"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -47,18 +49,18 @@ func TestPriorityQueue_Less(t *testing.T) {
 			in: priorityQueue{
 				&queueEntry{
 					index: 0,
-					cell: &Cell{
-						GCost: 0,
-						HCost: 3,
-						FCost: 3,
+					node: &node{
+						gCost: 0,
+						hCost: 3,
+						fCost: 3,
 					},
 				},
 				&queueEntry{
 					index: 1,
-					cell: &Cell{
-						GCost: 1,
-						HCost: 4,
-						FCost: 5,
+					node: &node{
+						gCost: 1,
+						hCost: 4,
+						fCost: 5,
 					},
 				},
 			},
@@ -71,18 +73,18 @@ func TestPriorityQueue_Less(t *testing.T) {
 			in: priorityQueue{
 				&queueEntry{
 					index: 0,
-					cell: &Cell{
-						GCost: 2,
-						HCost: 2,
-						FCost: 4,
+					node: &node{
+						gCost: 2,
+						hCost: 2,
+						fCost: 4,
 					},
 				},
 				&queueEntry{
 					index: 1,
-					cell: &Cell{
-						GCost: 1,
-						HCost: 3,
-						FCost: 4,
+					node: &node{
+						gCost: 1,
+						hCost: 3,
+						fCost: 4,
 					},
 				},
 			},
@@ -95,18 +97,18 @@ func TestPriorityQueue_Less(t *testing.T) {
 			in: priorityQueue{
 				&queueEntry{
 					index: 0,
-					cell: &Cell{
-						GCost: 0,
-						HCost: 3,
-						FCost: 3,
+					node: &node{
+						gCost: 0,
+						hCost: 3,
+						fCost: 3,
 					},
 				},
 				&queueEntry{
 					index: 1,
-					cell: &Cell{
-						GCost: 1,
-						HCost: 4,
-						FCost: 5,
+					node: &node{
+						gCost: 1,
+						hCost: 4,
+						fCost: 5,
 					},
 				},
 			},
@@ -119,10 +121,10 @@ func TestPriorityQueue_Less(t *testing.T) {
 			in: priorityQueue{
 				&queueEntry{
 					index: 0,
-					cell: &Cell{
-						GCost: 0,
-						HCost: 3,
-						FCost: 3,
+					node: &node{
+						gCost: 0,
+						hCost: 3,
+						fCost: 3,
 					},
 				},
 				nil,
@@ -132,18 +134,18 @@ func TestPriorityQueue_Less(t *testing.T) {
 			want: false,
 		},
 		{
-			name: "nil cells",
+			name: "nil nodes",
 			in: priorityQueue{
 				&queueEntry{
 					index: 0,
-					cell:  nil,
+					node:  nil,
 				},
 				&queueEntry{
 					index: 1,
-					cell: &Cell{
-						GCost: 1,
-						HCost: 4,
-						FCost: 5,
+					node: &node{
+						gCost: 1,
+						hCost: 4,
+						fCost: 5,
 					},
 				},
 			},
@@ -188,11 +190,11 @@ func TestPriorityQueue_Swap(t *testing.T) {
 func TestPriorityQueue_Push(t *testing.T) {
 	queue := priorityQueue{}
 
-	cell := &Cell{}
-	queue.Push(cell)
+	n := &node{}
+	queue.Push(n)
 
 	assert.Len(t, queue, 1)
-	assert.Equal(t, cell, queue[0].cell)
+	assert.Equal(t, n, queue[0].node)
 	assert.Equal(t, 0, queue[0].index)
 
 	// push nil
@@ -201,23 +203,23 @@ func TestPriorityQueue_Push(t *testing.T) {
 }
 
 func TestPriorityQueue_Pop(t *testing.T) {
-	cell := &Cell{}
+	n := &node{}
 	queue := priorityQueue{}
 
 	// pop from empty queue
 	assert.Nil(t, queue.Pop())
 
 	// pop from non-empty queue
-	queue.Push(cell)
+	queue.Push(n)
 
 	got := queue.Pop()
-	assert.True(t, cell == got)
+	assert.True(t, n == got)
 	assert.Len(t, queue, 0)
 }
 
 func TestPriorityQueue_GetIndex(t *testing.T) {
-	inQueue := &Cell{}
-	outQueue := &Cell{}
+	inQueue := &node{}
+	outQueue := &node{}
 
 	queue := priorityQueue{}
 	queue.Push(inQueue)
@@ -226,3 +228,85 @@ func TestPriorityQueue_GetIndex(t *testing.T) {
 	assert.Equal(t, -1, queue.GetIndex(outQueue))
 	assert.Equal(t, -1, queue.GetIndex(nil))
 }
+
+func TestPriorityQueue_GetIndex_AfterHeapOperations(t *testing.T) {
+	queue := &priorityQueue{}
+
+	nodes := make([]*node, 10)
+	for i := range nodes {
+		nodes[i] = &node{fCost: (i * 7) % 10}
+		heap.Push(queue, nodes[i])
+	}
+
+	// decrease the key of a node in place
+	nodes[9].fCost = -1
+	heap.Fix(queue, queue.GetIndex(nodes[9]))
+
+	popped := heap.Pop(queue).(*node)
+	assert.True(t, popped == nodes[9])
+	assert.Equal(t, -1, queue.GetIndex(popped))
+
+	// the index of every node still in the queue points to the node
+	for _, n := range nodes[:9] {
+		i := queue.GetIndex(n)
+		assert.True(t, (*queue)[i].node == n)
+	}
+
+	// a node of another queue is not found
+	other := &priorityQueue{}
+	heap.Push(other, &node{})
+	assert.Equal(t, -1, queue.GetIndex((*other)[0].node))
+}
+
+// scanIndex returns the index of the given node in the queue by scanning the whole queue,
+// as the queue did before the nodes referred to their entries.
+func scanIndex(pq *priorityQueue, n *node) int {
+	for _, entry := range *pq {
+		if entry.node == n {
+			return entry.index
+		}
+	}
+
+	return -1
+}
+
+func BenchmarkPriorityQueue_DecreaseKey(b *testing.B) {
+	for _, size := range []int{100, 1000, 10000} {
+		// newQueue returns a queue of the given size and its nodes
+		newQueue := func() (*priorityQueue, []*node) {
+			queue := &priorityQueue{}
+			nodes := make([]*node, size)
+			for i := range nodes {
+				nodes[i] = &node{fCost: size + i}
+				heap.Push(queue, nodes[i])
+			}
+
+			return queue, nodes
+		}
+
+		b.Run(fmt.Sprintf("scan and re-push/%d", size), func(b *testing.B) {
+			queue, nodes := newQueue()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				n := nodes[i%size]
+				heap.Remove(queue, scanIndex(queue, n))
+				n.fCost--
+				heap.Push(queue, n)
+			}
+		})
+
+		b.Run(fmt.Sprintf("index and fix/%d", size), func(b *testing.B) {
+			queue, nodes := newQueue()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				n := nodes[i%size]
+				n.fCost--
+				heap.Fix(queue, queue.GetIndex(n))
+			}
+		})
+	}
+}
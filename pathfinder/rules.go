@@ -0,0 +1,208 @@
+package pathfinder
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultMaxSpeed        = 3
+	defaultMaxAcceleration = 1
+)
+
+// maxRulesSpeed is the maximal speed the rules allow: the number of the velocities of the hopper,
+// and so the number of the states the searches go through, grows with the square of the speed.
+const maxRulesSpeed = 63
+
+// Rules represents the rules of the Hopping Race game the hoppers follow.
+//
+// The zero value of Rules stands for the classic rules:
+// the speed is limited by 3 squares in each direction,
+// and the hopper can change it by -1, 0 or 1 in both directions at once.
+type Rules struct {
+	// MaxSpeed is the maximal absolute speed of the hopper in each direction.
+	// Zero stands for the default value, which is 3.
+	MaxSpeed int
+	// MaxAcceleration is the maximal absolute change of the hopper speed in each direction per hop.
+	// Zero stands for the default value, which is 1.
+	MaxAcceleration int
+	// AxisAcceleration indicates whether the hopper can change its speed
+	// in a single direction per hop only (i.e., no diagonal thrust).
+	AxisAcceleration bool
+	// StopAtFinish indicates whether the hopper must be able to come to rest on the finish cell.
+	// If set, landing on the finish cell finishes the race only if the hopper can change
+	// its velocity to (0,0) with a single allowed acceleration right after landing.
+	// The resting itself is not counted as a hop.
+	StopAtFinish bool
+	// FlightCollision indicates whether the hopper collides with the obstacles it flies over.
+	// If set, the hopper can make a hop only if all the cells crossed by the straight line
+	// from the departure cell to the landing cell are available, not only the landing cell.
+	FlightCollision bool
+}
+
+// Rules settings keys used by Apply.
+const (
+	RulesMaxSpeed         = "max-speed"
+	RulesMaxAcceleration  = "max-acceleration"
+	RulesAxisAcceleration = "axis-acceleration"
+	RulesStopAtFinish     = "stop-at-finish"
+	RulesFlightCollision  = "flight-collision"
+)
+
+// Apply returns a copy of the rules with the given settings applied.
+//
+// The settings are key-value pairs, where the key is one of the Rules* constants
+// and the value is its string representation (e.g., "max-speed": "5").
+// An error is returned if a key is unknown or a value cannot be parsed.
+func (r Rules) Apply(settings map[string]string) (Rules, error) {
+	for key, value := range settings {
+		var err error
+
+		switch key {
+		case RulesMaxSpeed:
+			r.MaxSpeed, err = strconv.Atoi(value)
+		case RulesMaxAcceleration:
+			r.MaxAcceleration, err = strconv.Atoi(value)
+		case RulesAxisAcceleration:
+			r.AxisAcceleration, err = strconv.ParseBool(value)
+		case RulesStopAtFinish:
+			r.StopAtFinish, err = strconv.ParseBool(value)
+		case RulesFlightCollision:
+			r.FlightCollision, err = strconv.ParseBool(value)
+		default:
+			return r, errors.Errorf("unknown rules setting %q", key)
+		}
+
+		if err != nil {
+			return r, errors.Wrapf(err, "failed to parse rules setting %q", key)
+		}
+	}
+
+	return r, r.Validate()
+}
+
+// Validate returns an error if the rules cannot be followed by a hopper.
+func (r Rules) Validate() error {
+	if r.MaxSpeed < 0 {
+		return errors.New("maximal speed must not be negative")
+	}
+	if r.MaxAcceleration < 0 {
+		return errors.New("maximal acceleration must not be negative")
+	}
+	if r.GetMaxSpeed() > maxRulesSpeed {
+		return errors.Errorf("maximal speed must not exceed %d", maxRulesSpeed)
+	}
+	// no acceleration turns a speed in range into another one by more than twice the maximal speed
+	if r.GetMaxAcceleration() > 2*r.GetMaxSpeed() {
+		return errors.New("maximal acceleration must not exceed twice the maximal speed")
+	}
+
+	return nil
+}
+
+// GetMaxSpeed returns the maximal absolute speed of the hopper in each direction.
+func (r Rules) GetMaxSpeed() int {
+	if r.MaxSpeed == 0 {
+		return defaultMaxSpeed
+	}
+
+	return r.MaxSpeed
+}
+
+// GetMaxAcceleration returns the maximal absolute change of the hopper speed in each direction per hop.
+func (r Rules) GetMaxAcceleration() int {
+	if r.MaxAcceleration == 0 {
+		return defaultMaxAcceleration
+	}
+
+	return r.MaxAcceleration
+}
+
+// accelerationsKey identifies a set of the velocity changes allowed by the rules.
+type accelerationsKey struct {
+	maxAcceleration int
+	axis            bool
+}
+
+// accelerationSets caches the sets of the velocity changes by their accelerationsKey,
+// as the searches ask for them on every expansion.
+var accelerationSets sync.Map
+
+// Accelerations returns all the velocity changes the hopper is allowed to make before a hop.
+//
+// The changes turning a speed in range into one out of range are left out.
+// The set is calculated once for the rules and shared, so it must not be modified.
+func (r Rules) Accelerations() []Acceleration {
+	key := accelerationsKey{
+		maxAcceleration: min(r.GetMaxAcceleration(), 2*r.GetMaxSpeed()),
+		axis:            r.AxisAcceleration,
+	}
+	if cached, ok := accelerationSets.Load(key); ok {
+		return cached.([]Acceleration)
+	}
+
+	var set []Acceleration
+
+	for y := -key.maxAcceleration; y <= key.maxAcceleration; y++ {
+		for x := -key.maxAcceleration; x <= key.maxAcceleration; x++ {
+			// the speed can be changed in a single direction only
+			if key.axis && x != 0 && y != 0 {
+				continue
+			}
+
+			set = append(set, Acceleration{X: x, Y: y})
+		}
+	}
+
+	cached, _ := accelerationSets.LoadOrStore(key, set)
+
+	return cached.([]Acceleration)
+}
+
+// CanStop reports whether the hopper moving with the given velocity can come to rest
+// (i.e., change its velocity to (0,0)) with a single allowed acceleration.
+func (r Rules) CanStop(speed Velocity) bool {
+	maxAcceleration := r.GetMaxAcceleration()
+
+	if speed.X < -maxAcceleration || speed.X > maxAcceleration ||
+		speed.Y < -maxAcceleration || speed.Y > maxAcceleration {
+		return false
+	}
+
+	// the speed can be changed in a single direction only
+	if r.AxisAcceleration && speed.X != 0 && speed.Y != 0 {
+		return false
+	}
+
+	return true
+}
+
+// Finishes reports whether the hopper has finished the race
+// with the hop landing on the given cell according to the rules.
+//
+// If the hopper must stop at the finish, it has to land on a cell of the finish
+// with the velocity it can come to rest from, even if the finish can be reached by crossing it.
+func (r Rules) Finishes(cell *Cell, finish Goal) bool {
+	_, ok := r.FinishesAt(cell, finish)
+	return ok
+}
+
+// FinishesAt is like Finishes, but it also returns the position of the finish cell
+// the race is finished at.
+func (r Rules) FinishesAt(cell *Cell, finish Goal) (Position, bool) {
+	if cell == nil || finish == nil {
+		return Position{}, false
+	}
+
+	if r.StopAtFinish {
+		if !finish.Contains(cell.X, cell.Y) || !r.CanStop(cell.Speed) {
+			return Position{}, false
+		}
+
+		return Position{X: cell.X, Y: cell.Y}, true
+	}
+
+	return finish.Reached(cell)
+}
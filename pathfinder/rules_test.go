@@ -0,0 +1,212 @@
+package pathfinder
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRules_Apply(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    Rules
+		settings map[string]string
+		want     Rules
+		err      error
+	}{
+		{
+			name:     "no settings",
+			rules:    Rules{MaxSpeed: 4},
+			settings: nil,
+			want:     Rules{MaxSpeed: 4},
+		},
+		{
+			name:  "all settings",
+			rules: Rules{MaxSpeed: 4},
+			settings: map[string]string{
+				RulesMaxSpeed:         "5",
+				RulesMaxAcceleration:  "2",
+				RulesAxisAcceleration: "true",
+				RulesStopAtFinish:     "true",
+				RulesFlightCollision:  "true",
+			},
+			want: Rules{MaxSpeed: 5, MaxAcceleration: 2, AxisAcceleration: true, StopAtFinish: true, FlightCollision: true},
+		},
+		{
+			name:     "unknown setting",
+			settings: map[string]string{"min-speed": "1"},
+			err:      errors.New(`unknown rules setting "min-speed"`),
+		},
+		{
+			name:     "invalid value",
+			settings: map[string]string{RulesMaxSpeed: "fast"},
+			err:      errors.New(`failed to parse rules setting "max-speed"`),
+		},
+		{
+			name:     "negative speed",
+			settings: map[string]string{RulesMaxSpeed: "-1"},
+			err:      errors.New("maximal speed must not be negative"),
+		},
+		{
+			name:     "negative acceleration",
+			settings: map[string]string{RulesMaxAcceleration: "-1"},
+			err:      errors.New("maximal acceleration must not be negative"),
+		},
+		{
+			name:     "speed too high",
+			settings: map[string]string{RulesMaxSpeed: "100000"},
+			err:      errors.New("maximal speed must not exceed 63"),
+		},
+		{
+			name:     "acceleration too high",
+			settings: map[string]string{RulesMaxAcceleration: "100000"},
+			err:      errors.New("maximal acceleration must not exceed twice the maximal speed"),
+		},
+		{
+			name:     "acceleration up to twice the speed",
+			settings: map[string]string{RulesMaxSpeed: "2", RulesMaxAcceleration: "4"},
+			want:     Rules{MaxSpeed: 2, MaxAcceleration: 4},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.rules.Apply(test.settings)
+
+			if test.err != nil {
+				assert.ErrorContains(t, err, test.err.Error())
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestRules_Defaults(t *testing.T) {
+	assert.Equal(t, 3, Rules{}.GetMaxSpeed())
+	assert.Equal(t, 1, Rules{}.GetMaxAcceleration())
+
+	assert.Equal(t, 5, Rules{MaxSpeed: 5}.GetMaxSpeed())
+	assert.Equal(t, 2, Rules{MaxAcceleration: 2}.GetMaxAcceleration())
+}
+
+func TestRules_Accelerations(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules Rules
+		want  []Acceleration
+	}{
+		{
+			name:  "classic rules",
+			rules: Rules{},
+			want: []Acceleration{
+				{X: -1, Y: -1}, {X: 0, Y: -1}, {X: 1, Y: -1},
+				{X: -1, Y: 0}, {X: 0, Y: 0}, {X: 1, Y: 0},
+				{X: -1, Y: 1}, {X: 0, Y: 1}, {X: 1, Y: 1},
+			},
+		},
+		{
+			name:  "axis acceleration",
+			rules: Rules{AxisAcceleration: true},
+			want: []Acceleration{
+				{X: 0, Y: -1},
+				{X: -1, Y: 0}, {X: 0, Y: 0}, {X: 1, Y: 0},
+				{X: 0, Y: 1},
+			},
+		},
+		{
+			name:  "axis acceleration by 2",
+			rules: Rules{MaxAcceleration: 2, AxisAcceleration: true},
+			want: []Acceleration{
+				{X: 0, Y: -2},
+				{X: 0, Y: -1},
+				{X: -2, Y: 0}, {X: -1, Y: 0}, {X: 0, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 0},
+				{X: 0, Y: 1},
+				{X: 0, Y: 2},
+			},
+		},
+		{
+			name:  "acceleration limited by twice the speed",
+			rules: Rules{MaxSpeed: 1, MaxAcceleration: 5, AxisAcceleration: true},
+			want: []Acceleration{
+				{X: 0, Y: -2},
+				{X: 0, Y: -1},
+				{X: -2, Y: 0}, {X: -1, Y: 0}, {X: 0, Y: 0}, {X: 1, Y: 0}, {X: 2, Y: 0},
+				{X: 0, Y: 1},
+				{X: 0, Y: 2},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, test.rules.Accelerations())
+
+			// the set is calculated once
+			assert.Zero(t, testing.AllocsPerRun(10, func() { test.rules.Accelerations() }))
+		})
+	}
+}
+
+func TestRules_CanStop(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules Rules
+		speed Velocity
+		want  bool
+	}{
+		{
+			name:  "at rest",
+			rules: Rules{},
+			speed: Velocity{X: 0, Y: 0},
+			want:  true,
+		},
+		{
+			name:  "slow diagonal move",
+			rules: Rules{},
+			speed: Velocity{X: 1, Y: -1},
+			want:  true,
+		},
+		{
+			name:  "fast move",
+			rules: Rules{},
+			speed: Velocity{X: 2, Y: 0},
+			want:  false,
+		},
+		{
+			name:  "fast move with strong acceleration",
+			rules: Rules{MaxAcceleration: 2},
+			speed: Velocity{X: 2, Y: -2},
+			want:  true,
+		},
+		{
+			name:  "slow diagonal move with axis acceleration",
+			rules: Rules{AxisAcceleration: true},
+			speed: Velocity{X: 1, Y: -1},
+			want:  false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, test.rules.CanStop(test.speed))
+		})
+	}
+}
+
+func TestRules_Finishes(t *testing.T) {
+	finish := NewLandingGoal(&Cell{X: 2, Y: 2})
+
+	assert.False(t, Rules{}.Finishes(nil, finish))
+	assert.False(t, Rules{}.Finishes(&Cell{X: 1, Y: 2}, finish))
+	assert.True(t, Rules{}.Finishes(&Cell{X: 2, Y: 2, Speed: Velocity{X: 3, Y: 0}}, finish))
+
+	assert.False(t, Rules{StopAtFinish: true}.Finishes(&Cell{X: 2, Y: 2, Speed: Velocity{X: 3, Y: 0}}, finish))
+	assert.True(t, Rules{StopAtFinish: true}.Finishes(&Cell{X: 2, Y: 2, Speed: Velocity{X: 1, Y: 0}}, finish))
+
+	line := NewFinishLine(2, 0, 2, 4)
+
+	assert.True(t, Rules{}.Finishes(&Cell{X: 3, Y: 2, Speed: Velocity{X: 3, Y: 0}}, line))
+	assert.False(t, Rules{StopAtFinish: true}.Finishes(&Cell{X: 3, Y: 2, Speed: Velocity{X: 1, Y: 0}}, line))
+	assert.True(t, Rules{StopAtFinish: true}.Finishes(&Cell{X: 2, Y: 2, Speed: Velocity{X: 1, Y: 0}}, line))
+}
@@ -0,0 +1,222 @@
+package pathfinder
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Reasons of aborting a search on exceeding its limits.
+var (
+	// ErrMaxExpansions is the reason of aborting a search that has expanded the maximal number of states.
+	ErrMaxExpansions = errors.New("expansion limit exceeded")
+	// ErrMaxOpen is the reason of aborting a search holding more states waiting for expansion than allowed.
+	ErrMaxOpen = errors.New("open set limit exceeded")
+	// ErrDeadline is the reason of aborting a search running past its deadline.
+	ErrDeadline = errors.New("search deadline exceeded")
+)
+
+// AbortedError is returned by a search aborted before finding the optimal race,
+// either because of exceeding its limits or because its context is done.
+type AbortedError struct {
+	// Reason is the reason of aborting the search:
+	// one of ErrMaxExpansions, ErrMaxOpen, ErrDeadline or the error of the search context.
+	Reason error
+	// Partial is the best partial race found so far:
+	// the race to the discovered state estimated to be the closest one to the finish.
+	// It does not finish the race, so its finish is the landing cell of its last hop.
+	Partial *Solution
+}
+
+// Error returns the description of the error.
+func (e *AbortedError) Error() string {
+	return "search aborted: " + e.Reason.Error()
+}
+
+// Unwrap returns the reason of aborting the search.
+func (e *AbortedError) Unwrap() error {
+	return e.Reason
+}
+
+// SearchStats holds the statistics of a single search.
+type SearchStats struct {
+	// Expanded is the number of states expanded by the search (i.e., whose neighbors have been evaluated).
+	Expanded int `json:"expanded"`
+	// Generated is the number of states discovered by the search, including the start states.
+	Generated int `json:"generated"`
+	// Reopened is the number of expanded states put back to the queue for a shorter path found to them.
+	Reopened int `json:"reopened"`
+	// PeakOpen is the maximal number of states waiting for expansion at once.
+	PeakOpen int `json:"peak_open"`
+	// Duration is the wall time of the search (in nanoseconds in JSON).
+	Duration time.Duration `json:"duration"`
+}
+
+// String returns the string representation of the statistics.
+func (s SearchStats) String() string {
+	return fmt.Sprintf("expanded=%d generated=%d reopened=%d peak-open=%d time=%s",
+		s.Expanded, s.Generated, s.Reopened, s.PeakOpen, s.Duration)
+}
+
+// open records the given number of states waiting for expansion.
+func (s *SearchStats) open(n int) {
+	s.PeakOpen = max(s.PeakOpen, n)
+}
+
+// SearchOption provides a way to configure a single search.
+type SearchOption func(l *searchSettings)
+
+// WithStats makes the search report its statistics to the given stats once it is over,
+// whether the race is found or not, or the search is aborted.
+func WithStats(stats *SearchStats) SearchOption {
+	return func(l *searchSettings) {
+		l.stats = stats
+	}
+}
+
+// WithMaxExpansions limits the number of states a search may expand.
+// A non-positive number means no limit.
+func WithMaxExpansions(n int) SearchOption {
+	return func(l *searchSettings) {
+		l.maxExpansions = n
+	}
+}
+
+// WithMaxOpen limits the number of states waiting for expansion a search may hold at once.
+// A non-positive number means no limit.
+func WithMaxOpen(n int) SearchOption {
+	return func(l *searchSettings) {
+		l.maxOpen = n
+	}
+}
+
+// WithDeadline sets the time a search is aborted at.
+// A zero time means no deadline.
+func WithDeadline(deadline time.Time) SearchOption {
+	return func(l *searchSettings) {
+		l.deadline = deadline
+	}
+}
+
+// WithTimeout sets the time a search is aborted after since it has started.
+// A non-positive timeout means no deadline.
+func WithTimeout(timeout time.Duration) SearchOption {
+	return func(l *searchSettings) {
+		if timeout > 0 {
+			l.deadline = time.Now().Add(timeout)
+		}
+	}
+}
+
+// checkInterval is the number of expansions between the checks of the search context and deadline.
+const checkInterval = 64
+
+// searchSettings holds the settings of a single search: its limits and the statistics to report.
+type searchSettings struct {
+	// maxExpansions is the maximal number of expanded states; zero or less means no limit.
+	maxExpansions int
+	// maxOpen is the maximal number of states waiting for expansion; zero or less means no limit.
+	maxOpen int
+	// deadline is the time the search is aborted at; zero means no deadline.
+	deadline time.Time
+
+	// stats is the statistics the search reports to, if any.
+	stats *SearchStats
+	// started is the time the search has started at.
+	started time.Time
+}
+
+// newSearchSettings returns the search settings set by the given options.
+func newSearchSettings(opts []SearchOption) *searchSettings {
+	l := &searchSettings{
+		started: time.Now(),
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return l
+}
+
+// report reports the given statistics of the search if requested.
+func (l *searchSettings) report(stats *SearchStats) {
+	if l.stats == nil {
+		return
+	}
+
+	*l.stats = *stats
+	l.stats.Duration = time.Since(l.started)
+}
+
+// check returns the reason to abort the search that has expanded the given number of states
+// and holds the given number of states waiting for expansion, or nil if the search may go on.
+//
+// The context and the deadline are checked once per checkInterval expansions only.
+func (l *searchSettings) check(ctx context.Context, expanded, open int) error {
+	if l.maxExpansions > 0 && expanded >= l.maxExpansions {
+		return ErrMaxExpansions
+	}
+	if l.maxOpen > 0 && open > l.maxOpen {
+		return ErrMaxOpen
+	}
+
+	if expanded%checkInterval != 0 {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !l.deadline.IsZero() && time.Now().After(l.deadline) {
+		return ErrDeadline
+	}
+
+	return nil
+}
+
+// abort returns the error of the search aborted for the given reason,
+// carrying the race to the discovered state with the lowest heuristic estimate
+// (the cheaper one among the equally estimated states).
+func abort(reason error, grid *Grid, nodes map[State]*node, h Heuristic, finish Goal, rules Rules) error {
+	var best *node
+	bestEstimate := 0
+
+	for _, n := range nodes {
+		e := estimate(h, n.cell, finish, rules)
+		if best == nil || e < bestEstimate || e == bestEstimate && isBetterPartial(n, best) {
+			best, bestEstimate = n, e
+		}
+	}
+
+	err := &AbortedError{Reason: reason}
+	if best != nil {
+		err.Partial = newSolution(grid, best.path(), Position{X: best.cell.X, Y: best.cell.Y})
+	}
+
+	return err
+}
+
+// isBetterPartial reports whether the race to node a is preferred to the race to node b
+// if both are equally estimated: the cheaper race is preferred,
+// and the order of states makes the choice independent of the order of discovery.
+func isBetterPartial(a, b *node) bool {
+	if a.gCost != b.gCost {
+		return a.gCost < b.gCost
+	}
+
+	sa, sb := a.state(), b.state()
+	switch {
+	case sa.Y != sb.Y:
+		return sa.Y < sb.Y
+	case sa.X != sb.X:
+		return sa.X < sb.X
+	case sa.Speed.Y != sb.Speed.Y:
+		return sa.Speed.Y < sb.Speed.Y
+	case sa.Speed.X != sb.Speed.X:
+		return sa.Speed.X < sb.Speed.X
+	default:
+		return sa.Time < sb.Time
+	}
+}
@@ -0,0 +1,207 @@
+package pathfinder
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchSettings_Check(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		opts     []SearchOption
+		expanded int
+		open     int
+		want     error
+	}{
+		{
+			name:     "no limits",
+			ctx:      context.Background(),
+			expanded: 1000,
+			open:     1000,
+			want:     nil,
+		},
+		{
+			name:     "non-positive limits",
+			ctx:      context.Background(),
+			opts:     []SearchOption{WithMaxExpansions(0), WithMaxOpen(-1), WithTimeout(0), WithDeadline(time.Time{})},
+			expanded: 1000,
+			open:     1000,
+			want:     nil,
+		},
+		{
+			name:     "expansions within the limit",
+			ctx:      context.Background(),
+			opts:     []SearchOption{WithMaxExpansions(10)},
+			expanded: 9,
+			want:     nil,
+		},
+		{
+			name:     "expansions exceeding the limit",
+			ctx:      context.Background(),
+			opts:     []SearchOption{WithMaxExpansions(10)},
+			expanded: 10,
+			want:     ErrMaxExpansions,
+		},
+		{
+			name: "open set within the limit",
+			ctx:  context.Background(),
+			opts: []SearchOption{WithMaxOpen(10)},
+			open: 10,
+			want: nil,
+		},
+		{
+			name: "open set exceeding the limit",
+			ctx:  context.Background(),
+			opts: []SearchOption{WithMaxOpen(10)},
+			open: 11,
+			want: ErrMaxOpen,
+		},
+		{
+			name: "deadline passed",
+			ctx:  context.Background(),
+			opts: []SearchOption{WithDeadline(time.Now().Add(-time.Second))},
+			want: ErrDeadline,
+		},
+		{
+			name: "timeout not expired",
+			ctx:  context.Background(),
+			opts: []SearchOption{WithTimeout(time.Hour)},
+			want: nil,
+		},
+		{
+			name: "context canceled",
+			ctx:  canceled,
+			want: context.Canceled,
+		},
+		{
+			name:     "context checked periodically only",
+			ctx:      canceled,
+			expanded: checkInterval + 1,
+			want:     nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := newSearchSettings(test.opts).check(test.ctx, test.expanded, test.open)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestFindPath_Aborted(t *testing.T) {
+	grid := NewGrid(1, 30)
+	starts := []*Cell{{X: 0, Y: 0}}
+	finish := NewLandingGoal(&Cell{X: 29, Y: 0})
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	pathfinders := map[string]Pathfinder{
+		"A*":  NewGridPathfinder(grid, VelocityDistance, Rules{}),
+		"BFS": NewBreadthFirstPathfinder(grid, Rules{}),
+	}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		opts []SearchOption
+		want error
+	}{
+		{
+			name: "expansion limit",
+			ctx:  context.Background(),
+			opts: []SearchOption{WithMaxExpansions(3)},
+			want: ErrMaxExpansions,
+		},
+		{
+			name: "open set limit",
+			ctx:  context.Background(),
+			opts: []SearchOption{WithMaxOpen(2)},
+			want: ErrMaxOpen,
+		},
+		{
+			name: "deadline",
+			ctx:  context.Background(),
+			opts: []SearchOption{WithDeadline(time.Now().Add(-time.Second))},
+			want: ErrDeadline,
+		},
+		{
+			name: "canceled context",
+			ctx:  canceled,
+			want: context.Canceled,
+		},
+	}
+	for name, pf := range pathfinders {
+		for _, test := range tests {
+			t.Run(name+" "+test.name, func(t *testing.T) {
+				got, err := pf.FindPath(test.ctx, starts, finish, test.opts...)
+				assert.Nil(t, got)
+				assert.ErrorIs(t, err, test.want)
+				assert.ErrorContains(t, err, "search aborted")
+
+				var aborted *AbortedError
+				if assert.True(t, errors.As(err, &aborted)) && assert.NotNil(t, aborted.Partial) {
+					// the best partial race starts at the start cell and leads towards the finish
+					assert.Equal(t, State{X: 0, Y: 0}, aborted.Partial.Start)
+					if aborted.Partial.Len() > 0 {
+						last := aborted.Partial.Hops[aborted.Partial.Len()-1]
+						assert.Equal(t, Position{X: last.X, Y: last.Y}, aborted.Partial.Finish)
+						assert.Positive(t, last.X)
+					}
+				}
+			})
+		}
+
+		t.Run(name+" limits not exceeded", func(t *testing.T) {
+			got, err := pf.FindPath(context.Background(), starts, finish, WithMaxExpansions(1000), WithMaxOpen(1000), WithTimeout(time.Minute))
+			assert.NoError(t, err)
+			assert.Equal(t, 11, got.Len())
+		})
+	}
+}
+
+func TestFindPath_Stats(t *testing.T) {
+	grid := NewGrid(1, 3)
+	starts := []*Cell{{X: 0, Y: 0}}
+	finish := NewLandingGoal(&Cell{X: 2, Y: 0})
+
+	pathfinders := map[string]Pathfinder{
+		"A*":  NewGridPathfinder(grid, VelocityDistance, Rules{}),
+		"BFS": NewBreadthFirstPathfinder(grid, Rules{}),
+	}
+
+	for name, pf := range pathfinders {
+		t.Run(name, func(t *testing.T) {
+			var stats SearchStats
+			got, err := pf.FindPath(context.Background(), starts, finish, WithStats(&stats))
+			assert.NoError(t, err)
+			assert.Equal(t, 2, got.Len())
+
+			// the start state and the state reached by the first hop are expanded,
+			// the state reached by the second hop finishes the race
+			stats.Duration = 0
+			assert.Equal(t, SearchStats{Expanded: 2, Generated: 3, Reopened: 0, PeakOpen: 1}, stats)
+		})
+
+		t.Run(name+" aborted", func(t *testing.T) {
+			var stats SearchStats
+			_, err := pf.FindPath(context.Background(), starts, finish, WithMaxExpansions(1), WithStats(&stats))
+			assert.ErrorIs(t, err, ErrMaxExpansions)
+			assert.Equal(t, 1, stats.Expanded)
+			assert.Equal(t, 2, stats.Generated)
+		})
+	}
+}
+
+func TestSearchStats_String(t *testing.T) {
+	stats := SearchStats{Expanded: 10, Generated: 42, Reopened: 1, PeakOpen: 20, Duration: 1500 * time.Microsecond}
+	assert.Equal(t, "expanded=10 generated=42 reopened=1 peak-open=20 time=1.5ms", stats.String())
+}
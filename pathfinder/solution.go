@@ -0,0 +1,111 @@
+package pathfinder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Acceleration represents a change of the hopper velocity right before a hop.
+type Acceleration struct {
+	// X is the change of the horizontal speed.
+	X int
+	// Y is the change of the vertical speed.
+	Y int
+}
+
+// String returns the string representation of the acceleration.
+func (a Acceleration) String() string {
+	return fmt.Sprintf("(%d,%d)", a.X, a.Y)
+}
+
+// Hop represents a single hop of a hopper.
+type Hop struct {
+	// X is the horizontal coordinate of the landing cell.
+	X int
+	// Y is the vertical coordinate of the landing cell.
+	Y int
+
+	// Speed is the velocity of the hopper on arrival to the landing cell.
+	Speed Velocity
+	// Acceleration is the velocity change applied before the hop.
+	Acceleration Acceleration
+}
+
+// String returns the string representation of the hop.
+func (h Hop) String() string {
+	return fmt.Sprintf("land at (%d,%d) with velocity %s after acceleration %s", h.X, h.Y, h.Speed, h.Acceleration)
+}
+
+// Solution represents a race of a hopper from the start to the finish.
+type Solution struct {
+	// Start is the state the hopper starts the race from.
+	Start State
+	// Hops is the sequence of hops made by the hopper to reach the finish.
+	Hops []Hop
+	// Finish is the position of the finish cell the race is finished at.
+	// It is the landing cell of the last hop unless the finish is crossed in flight.
+	Finish Position
+	// Cost is the sum of the costs of landing on the cells of the hops.
+	// It equals the number of hops unless the grid has terrain.
+	Cost int
+}
+
+// Len returns the number of hops of the solution.
+func (s *Solution) Len() int {
+	if s == nil {
+		return 0
+	}
+
+	return len(s.Hops)
+}
+
+// String returns the string representation of the solution:
+// the start position followed by a line per hop and the finish position.
+func (s *Solution) String() string {
+	if s == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("start at (%d,%d)", s.Start.X, s.Start.Y))
+	for i, h := range s.Hops {
+		sb.WriteString(fmt.Sprintf("\nhop %d: %s", i+1, h))
+	}
+	sb.WriteString(fmt.Sprintf("\nfinish at %s", s.Finish))
+
+	return sb.String()
+}
+
+// newSolution returns a solution built from the given path of states over the grid
+// finishing the race at the given position.
+//
+// The first cell of the path is considered to be the start of the race.
+func newSolution(grid *Grid, path []*Cell, finish Position) *Solution {
+	if len(path) == 0 {
+		return nil
+	}
+
+	s := &Solution{
+		Start:  path[0].State(),
+		Hops:   make([]Hop, 0, len(path)-1),
+		Finish: finish,
+	}
+
+	for i := 1; i < len(path); i++ {
+		prev, cur := path[i-1], path[i]
+
+		s.Hops = append(s.Hops, Hop{
+			X:     cur.X,
+			Y:     cur.Y,
+			Speed: cur.Speed,
+			Acceleration: Acceleration{
+				X: cur.Speed.X - prev.Speed.X,
+				Y: cur.Speed.Y - prev.Speed.Y,
+			},
+		})
+		s.Cost += grid.LandingCost(cur.X, cur.Y)
+	}
+
+	return s
+}
@@ -0,0 +1,75 @@
+package pathfinder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSolution_Len(t *testing.T) {
+	var nilSolution *Solution
+	assert.Equal(t, 0, nilSolution.Len())
+
+	s := &Solution{Hops: []Hop{{X: 1, Y: 1}, {X: 2, Y: 2}}}
+	assert.Equal(t, 2, s.Len())
+}
+
+func TestSolution_String(t *testing.T) {
+	tests := []struct {
+		name string
+		in   *Solution
+		want string
+	}{
+		{
+			name: "nil solution",
+			in:   nil,
+			want: "",
+		},
+		{
+			name: "solution with hops",
+			in: &Solution{
+				Start: State{X: 0, Y: 0},
+				Hops: []Hop{
+					{X: 1, Y: 1, Speed: Velocity{X: 1, Y: 1}, Acceleration: Acceleration{X: 1, Y: 1}},
+					{X: 2, Y: 1, Speed: Velocity{X: 1, Y: 0}, Acceleration: Acceleration{X: 0, Y: -1}},
+				},
+				Finish: Position{X: 2, Y: 1},
+			},
+			want: "start at (0,0)\n" +
+				"hop 1: land at (1,1) with velocity (1,1) after acceleration (1,1)\n" +
+				"hop 2: land at (2,1) with velocity (1,0) after acceleration (0,-1)\n" +
+				"finish at (2,1)",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, test.in.String())
+		})
+	}
+}
+
+func TestNewSolution(t *testing.T) {
+	grid := NewGrid(2, 4)
+	assert.Nil(t, newSolution(grid, nil, Position{}))
+
+	path := []*Cell{
+		{X: 0, Y: 0},
+		{X: 1, Y: 0, Speed: Velocity{X: 1, Y: 0}},
+		{X: 3, Y: 1, Speed: Velocity{X: 2, Y: 1}},
+	}
+
+	want := &Solution{
+		Start: State{X: 0, Y: 0},
+		Hops: []Hop{
+			{X: 1, Y: 0, Speed: Velocity{X: 1, Y: 0}, Acceleration: Acceleration{X: 1, Y: 0}},
+			{X: 3, Y: 1, Speed: Velocity{X: 2, Y: 1}, Acceleration: Acceleration{X: 1, Y: 1}},
+		},
+		Finish: Position{X: 2, Y: 1},
+		Cost:   2,
+	}
+	assert.Equal(t, want, newSolution(grid, path, Position{X: 2, Y: 1}))
+
+	// landing on the mud costs more
+	want.Cost = 4
+	assert.Equal(t, want, newSolution(grid.WithTerrain(Terrain{X1: 3, X2: 3, Y1: 1, Y2: 1, Cost: 2}), path, Position{X: 2, Y: 1}))
+}
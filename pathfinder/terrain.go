@@ -0,0 +1,109 @@
+package pathfinder
+
+// defaultLandingCost is the cost of landing on a cell not covered by any terrain.
+const defaultLandingCost = 1
+
+// Terrain represents an area of a grid changing the cost of landing on its cells
+// (e.g., mud making a hop more expensive or a boost pad making it cheaper).
+type Terrain struct {
+	X1 int
+	X2 int
+	Y1 int
+	Y2 int
+
+	// Cost is added to the cost of landing on each cell of the area, which is 1 otherwise.
+	// It may be negative, but landing on a cell never costs less than zero.
+	Cost int
+}
+
+// WithTerrain returns a copy of the grid with the given terrain.
+//
+// The terrain overrides the terrain given before, so a cell covered by several areas
+// gets the landing cost of the last one. The parts of the terrain out of the grid are ignored.
+// If any area makes landing cost less than zero, nil is returned.
+//
+// The copy shares the obstacles with the grid. The landing costs of a dense grid are stored per cell,
+// while a sparse grid looks up its terrain areas on every query, so the memory it takes
+// still depends on the number of areas only.
+func (g *Grid) WithTerrain(terrain ...Terrain) *Grid {
+	if g == nil {
+		return nil
+	}
+
+	w := *g
+	w.terrain = append([]Terrain{}, g.terrain...)
+
+	for _, t := range terrain {
+		if defaultLandingCost+t.Cost < 0 {
+			return nil
+		}
+
+		t.X1, t.X2, t.Y1, t.Y2 = max(t.X1, 0), min(t.X2, g.Cols-1), max(t.Y1, 0), min(t.Y2, g.Rows-1)
+		if t.X1 <= t.X2 && t.Y1 <= t.Y2 {
+			w.terrain = append(w.terrain, t)
+		}
+	}
+
+	if len(w.terrain) == 0 {
+		return &w
+	}
+
+	w.minLandingCost = defaultLandingCost
+	for _, t := range w.terrain {
+		w.minLandingCost = min(w.minLandingCost, defaultLandingCost+t.Cost)
+	}
+
+	if g.occupied != nil {
+		w.landingCosts = make([]int32, g.Rows*g.Cols)
+		for i := range w.landingCosts {
+			w.landingCosts[i] = defaultLandingCost
+		}
+
+		for _, t := range w.terrain {
+			for y := t.Y1; y <= t.Y2; y++ {
+				for x := t.X1; x <= t.X2; x++ {
+					w.landingCosts[w.index(x, y)] = int32(defaultLandingCost + t.Cost)
+				}
+			}
+		}
+	}
+
+	return &w
+}
+
+// Weighted reports whether the grid has terrain, so landing on its cells may cost differently.
+func (g *Grid) Weighted() bool {
+	return len(g.terrain) > 0
+}
+
+// LandingCost returns the cost of landing on the cell at the specified coordinates inside the grid:
+// 1 unless the cell is covered by terrain.
+func (g *Grid) LandingCost(x, y int) int {
+	switch {
+	case len(g.terrain) == 0:
+		return defaultLandingCost
+	case g.landingCosts != nil:
+		return int(g.landingCosts[g.index(x, y)])
+	}
+
+	// the last area covering the cell overrides the others
+	for i := len(g.terrain) - 1; i >= 0; i-- {
+		if t := g.terrain[i]; t.X1 <= x && x <= t.X2 && t.Y1 <= y && y <= t.Y2 {
+			return defaultLandingCost + t.Cost
+		}
+	}
+
+	return defaultLandingCost
+}
+
+// MinLandingCost returns the cost no landing on the grid is cheaper than.
+//
+// It is used to turn an estimate of the number of hops into an estimate of the cost of the hops
+// that never exceeds the real cost.
+func (g *Grid) MinLandingCost() int {
+	if len(g.terrain) == 0 {
+		return defaultLandingCost
+	}
+
+	return g.minLandingCost
+}
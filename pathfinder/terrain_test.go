@@ -0,0 +1,185 @@
+package pathfinder
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrid_WithTerrain(t *testing.T) {
+	tests := []struct {
+		name    string
+		terrain []Terrain
+		// costs are the landing costs of the cells of the 2x4 grid laid out row by row
+		costs []int
+		min   int
+	}{
+		{
+			name:    "no terrain",
+			terrain: nil,
+			costs:   []int{1, 1, 1, 1, 1, 1, 1, 1},
+			min:     1,
+		},
+		{
+			name:    "mud",
+			terrain: []Terrain{{X1: 1, X2: 2, Y1: 0, Y2: 1, Cost: 3}},
+			costs:   []int{1, 4, 4, 1, 1, 4, 4, 1},
+			min:     1,
+		},
+		{
+			name:    "boost pad",
+			terrain: []Terrain{{X1: 3, X2: 3, Y1: 1, Y2: 1, Cost: -1}},
+			costs:   []int{1, 1, 1, 1, 1, 1, 1, 0},
+			min:     0,
+		},
+		{
+			name: "later terrain overrides earlier one",
+			terrain: []Terrain{
+				{X1: 0, X2: 3, Y1: 0, Y2: 0, Cost: 2},
+				{X1: 2, X2: 3, Y1: 0, Y2: 1, Cost: 1},
+			},
+			costs: []int{3, 3, 2, 2, 1, 1, 2, 2},
+			min:   1,
+		},
+		{
+			name:    "terrain clamped to grid",
+			terrain: []Terrain{{X1: -5, X2: 0, Y1: 1, Y2: 10, Cost: 1}, {X1: 4, X2: 5, Y1: 0, Y2: 0, Cost: -1}},
+			costs:   []int{1, 1, 1, 1, 2, 1, 1, 1},
+			min:     1,
+		},
+	}
+	for _, test := range tests {
+		for _, sparse := range []bool{false, true} {
+			t.Run(test.name, func(t *testing.T) {
+				grid := newGrid(2, 4, sparse, Obstacle{X1: 0, X2: 0, Y1: 0, Y2: 0})
+				got := grid.WithTerrain(test.terrain...)
+
+				var costs []int
+				for y := 0; y < got.Rows; y++ {
+					for x := 0; x < got.Cols; x++ {
+						costs = append(costs, got.LandingCost(x, y))
+					}
+				}
+				assert.Equal(t, test.costs, costs)
+				assert.Equal(t, test.min, got.MinLandingCost())
+
+				// the obstacles are kept, while the grid itself is not changed
+				assert.Equal(t, gridMap(grid), gridMap(got))
+				assert.False(t, grid.Weighted())
+				assert.Equal(t, 1, grid.LandingCost(1, 0))
+			})
+		}
+	}
+}
+
+func TestGrid_WithTerrain_Invalid(t *testing.T) {
+	assert.Nil(t, NewGrid(2, 4).WithTerrain(Terrain{X1: 0, X2: 1, Y1: 0, Y2: 1, Cost: -2}))
+	assert.Nil(t, (*Grid)(nil).WithTerrain())
+}
+
+func TestGridPathfinder_FindPath_Terrain(t *testing.T) {
+	tests := []struct {
+		name    string
+		grid    *Grid
+		start   *Cell
+		finish  *Cell
+		terrain []Terrain
+		want    string
+		cost    int
+	}{
+		{
+			name:   "clear track",
+			grid:   NewGrid(1, 5),
+			start:  &Cell{X: 0, Y: 0},
+			finish: &Cell{X: 4, Y: 0},
+			want:   "(0,0) (1,0) (2,0) (4,0)",
+			cost:   3,
+		},
+		{
+			name:    "boost pad taken",
+			grid:    NewGrid(1, 5),
+			start:   &Cell{X: 0, Y: 0},
+			finish:  &Cell{X: 4, Y: 0},
+			terrain: []Terrain{{X1: 3, X2: 3, Y1: 0, Y2: 0, Cost: -1}},
+			want:    "(0,0) (1,0) (3,0) (4,0)",
+			cost:    2,
+		},
+		{
+			name:    "mud avoided with more hops",
+			grid:    NewGrid(1, 7),
+			start:   &Cell{X: 0, Y: 0},
+			finish:  &Cell{X: 6, Y: 0},
+			terrain: []Terrain{{X1: 3, X2: 3, Y1: 0, Y2: 0, Cost: 5}},
+			want:    "(0,0) (1,0) (2,0) (4,0) (6,0)",
+			cost:    4,
+		},
+		{
+			name:    "mud avoided around",
+			grid:    NewGrid(3, 5),
+			start:   &Cell{X: 0, Y: 1},
+			finish:  &Cell{X: 4, Y: 1},
+			terrain: []Terrain{{X1: 1, X2: 3, Y1: 1, Y2: 1, Cost: 10}},
+			cost:    3,
+		},
+	}
+	for _, test := range tests {
+		grid := test.grid.WithTerrain(test.terrain...)
+
+		pathfinders := map[string]Pathfinder{
+			"astar": NewGridPathfinder(grid, VelocityDistance, Rules{}),
+			"bfs":   NewBreadthFirstPathfinder(grid, Rules{}),
+		}
+		for name, pf := range pathfinders {
+			t.Run(test.name+"/"+name, func(t *testing.T) {
+				got, err := pf.FindPath(context.Background(), []*Cell{test.start}, NewLandingGoal(test.finish))
+				assert.NoError(t, err)
+
+				assert.Equal(t, test.cost, got.Cost)
+				if test.want != "" {
+					assert.Equal(t, test.want, racePositions(got))
+				}
+			})
+		}
+	}
+}
+
+// TestGridPathfinder_FindPath_TerrainReference checks that the A* algorithm
+// finds races of the same cost as the uniform-cost search on random tracks with terrain.
+func TestGridPathfinder_FindPath_TerrainReference(t *testing.T) {
+	rules := map[string]Rules{
+		"classic rules":  {},
+		"fast hoppers":   {MaxSpeed: 5, MaxAcceleration: 2},
+		"stop at finish": {StopAtFinish: true},
+	}
+
+	for rName, r := range rules {
+		t.Run(rName, func(t *testing.T) {
+			rnd := rand.New(rand.NewSource(1))
+
+			for i := 0; i < 200; i++ {
+				grid, starts, finish := randomTrack(rnd, 8, 8)
+				goal := NewLandingGoal(finish...)
+
+				var terrain []Terrain
+				for n := rnd.Intn(4); n > 0; n-- {
+					x, y := rnd.Intn(grid.Cols), rnd.Intn(grid.Rows)
+					terrain = append(terrain, Terrain{X1: x, X2: x + rnd.Intn(4), Y1: y, Y2: y + rnd.Intn(4), Cost: rnd.Intn(6) - 1})
+				}
+				grid = grid.WithTerrain(terrain...)
+
+				want, err := NewBreadthFirstPathfinder(grid, r).FindPath(context.Background(), starts, goal)
+				assert.NoError(t, err)
+
+				got, err := NewGridPathfinder(grid, VelocityDistance, r).FindPath(context.Background(), starts, goal)
+				assert.NoError(t, err)
+
+				assert.Equal(t, want == nil, got == nil, "starts %v, finish %v, terrain %v", starts, finish, terrain)
+				if got != nil && want != nil {
+					assert.Equal(t, want.Cost, got.Cost, "starts %v, finish %v, terrain %v", starts, finish, terrain)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package pathfinder
+
+// timed reports whether the obstacle is active at some hops only.
+func (o Obstacle) timed() bool {
+	return o.ActiveFrom > 0 || o.ActiveFor > 0 || o.Period > 0
+}
+
+// activeAt reports whether the obstacle is active at the given time (i.e., the number of hops made).
+func (o Obstacle) activeAt(time int) bool {
+	if time < o.ActiveFrom {
+		return false
+	}
+
+	elapsed := time - o.ActiveFrom
+	if o.Period > 0 {
+		elapsed %= o.Period
+	}
+
+	return o.ActiveFor == 0 || elapsed < o.ActiveFor
+}
+
+// timeline holds the timed obstacles of a grid.
+type timeline struct {
+	// obstacles are the timed obstacles clamped to the grid.
+	obstacles []Obstacle
+	// horizon is the time after which the obstacles change periodically only.
+	horizon int
+	// period is the period the obstacles repeat with after the horizon:
+	// the least common multiple of their periods.
+	period int
+}
+
+// newTimeline returns the timeline of the given timed obstacles.
+// The obstacles are expected to be clamped to the grid already.
+func newTimeline(obstacles []Obstacle) *timeline {
+	t := &timeline{
+		obstacles: obstacles,
+		period:    1,
+	}
+
+	for _, o := range obstacles {
+		if o.Period > 0 {
+			t.horizon = max(t.horizon, o.ActiveFrom)
+			t.period = lcm(t.period, o.Period)
+		} else {
+			// the obstacle is active within a single window
+			// (or forever since the start of the window)
+			t.horizon = max(t.horizon, o.ActiveFrom+o.ActiveFor)
+		}
+	}
+
+	return t
+}
+
+// clock returns the time the obstacles are the same at as at the given time,
+// folding the time after the horizon onto a single period.
+//
+// The states of the hopper hold the folded time, so the number of the states stays finite.
+func (t *timeline) clock(time int) int {
+	if time < t.horizon {
+		return time
+	}
+
+	return t.horizon + (time-t.horizon)%t.period
+}
+
+// isOccupied reports whether the cell at the specified coordinates is covered
+// by an obstacle active at the given time.
+func (t *timeline) isOccupied(x, y, time int) bool {
+	for _, o := range t.obstacles {
+		if o.X1 <= x && x <= o.X2 && o.Y1 <= y && y <= o.Y2 && o.activeAt(time) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Timed reports whether the grid has obstacles active at some hops only,
+// so the availability of its cells depends on the time.
+func (g *Grid) Timed() bool {
+	return g.timeline != nil
+}
+
+// IsAvailableAt reports whether the cell at the specified coordinates is available for landing
+// at the given time (i.e., after the given number of hops).
+// The cells out of the grid are not available.
+func (g *Grid) IsAvailableAt(x, y, time int) bool {
+	if !g.IsAvailable(x, y) {
+		return false
+	}
+
+	return g.timeline == nil || !g.timeline.isOccupied(x, y, time)
+}
+
+// lcm returns the least common multiple of the given positive numbers.
+func lcm(a, b int) int {
+	x, y := a, b
+	for y != 0 {
+		x, y = y, x%y
+	}
+
+	return a / x * b
+}
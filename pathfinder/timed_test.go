@@ -0,0 +1,269 @@
+package pathfinder
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObstacle_activeAt(t *testing.T) {
+	tests := []struct {
+		name     string
+		obstacle Obstacle
+		// active are the times from 0 to 9 the obstacle is active at
+		active []int
+	}{
+		{
+			name:     "always active",
+			obstacle: Obstacle{},
+			active:   []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		},
+		{
+			name:     "single window",
+			obstacle: Obstacle{ActiveFrom: 2, ActiveFor: 3},
+			active:   []int{2, 3, 4},
+		},
+		{
+			name:     "active since",
+			obstacle: Obstacle{ActiveFrom: 7},
+			active:   []int{7, 8, 9},
+		},
+		{
+			name:     "blinking",
+			obstacle: Obstacle{ActiveFor: 1, Period: 2},
+			active:   []int{0, 2, 4, 6, 8},
+		},
+		{
+			name:     "repeated window",
+			obstacle: Obstacle{ActiveFrom: 1, ActiveFor: 2, Period: 4},
+			active:   []int{1, 2, 5, 6, 9},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var active []int
+			for time := 0; time < 10; time++ {
+				if test.obstacle.activeAt(time) {
+					active = append(active, time)
+				}
+			}
+			assert.Equal(t, test.active, active)
+			assert.Equal(t, test.obstacle != Obstacle{}, test.obstacle.timed())
+		})
+	}
+}
+
+func TestTimeline_clock(t *testing.T) {
+	timeline := newTimeline([]Obstacle{
+		{ActiveFrom: 1, ActiveFor: 1, Period: 2},
+		{ActiveFrom: 3, ActiveFor: 2, Period: 3},
+		{ActiveFrom: 2, ActiveFor: 3},
+	})
+	assert.Equal(t, 5, timeline.horizon)
+	assert.Equal(t, 6, timeline.period)
+
+	for time := 0; time < 50; time++ {
+		clock := timeline.clock(time)
+		assert.Less(t, clock, timeline.horizon+timeline.period)
+
+		// the obstacles are the same at the folded time
+		for _, o := range timeline.obstacles {
+			assert.Equal(t, o.activeAt(time), o.activeAt(clock), "obstacle %v at time %d", o, time)
+		}
+	}
+}
+
+func TestGrid_IsAvailableAt(t *testing.T) {
+	grid := NewGrid(1, 5,
+		Obstacle{X1: 0, X2: 0, Y1: 0, Y2: 0},
+		Obstacle{X1: 2, X2: 3, Y1: 0, Y2: 0, ActiveFrom: 1, ActiveFor: 2},
+	)
+	assert.True(t, grid.Timed())
+	assert.False(t, NewGrid(1, 5, Obstacle{X1: 0, X2: 0, Y1: 0, Y2: 0}).Timed())
+
+	assert.False(t, grid.IsAvailableAt(0, 0, 0))
+	assert.True(t, grid.IsAvailableAt(2, 0, 0))
+	assert.False(t, grid.IsAvailableAt(2, 0, 1))
+	assert.False(t, grid.IsAvailableAt(3, 0, 2))
+	assert.True(t, grid.IsAvailableAt(3, 0, 3))
+	assert.False(t, grid.IsAvailableAt(5, 0, 3))
+
+	// the timed obstacles are ignored by the static availability
+	assert.True(t, grid.IsAvailable(2, 0))
+}
+
+func TestGrid_GetNeighbors_Timed(t *testing.T) {
+	grid := NewGrid(1, 5, Obstacle{X1: 2, X2: 2, Y1: 0, Y2: 0, ActiveFor: 1, Period: 2})
+
+	// the gate is open at odd times
+	got := grid.GetNeighbors(&Cell{X: 1, Y: 0, Speed: Velocity{X: 1}, Time: 2}, Rules{})
+	assert.Equal(t, []*Cell{
+		{X: 2, Y: 0, Available: true, Speed: Velocity{X: 1}, Time: 1},
+		{X: 3, Y: 0, Available: true, Speed: Velocity{X: 2}, Time: 1},
+	}, got)
+
+	// and closed at even ones
+	got = grid.GetNeighbors(&Cell{X: 1, Y: 0, Speed: Velocity{X: 1}, Time: 1}, Rules{})
+	assert.Equal(t, []*Cell{
+		{X: 3, Y: 0, Available: true, Speed: Velocity{X: 2}, Time: 0},
+	}, got)
+}
+
+func TestGridPathfinder_FindPath_Timed(t *testing.T) {
+	tests := []struct {
+		name     string
+		grid     *Grid
+		start    *Cell
+		finish   *Cell
+		flight   bool
+		want     string
+		unsolved bool
+	}{
+		{
+			name:   "no timed obstacles",
+			grid:   NewGrid(1, 7),
+			start:  &Cell{X: 0, Y: 0},
+			finish: &Cell{X: 6, Y: 0},
+			want:   "(0,0) (1,0) (3,0) (6,0)",
+		},
+		{
+			name:   "blinking gate avoided",
+			grid:   NewGrid(1, 7, Obstacle{X1: 3, X2: 3, Y1: 0, Y2: 0, ActiveFor: 1, Period: 2}),
+			start:  &Cell{X: 0, Y: 0},
+			finish: &Cell{X: 6, Y: 0},
+			want:   "(0,0) (1,0) (2,0) (4,0) (6,0)",
+		},
+		{
+			name:   "gate passed before it closes",
+			grid:   NewGrid(1, 7, Obstacle{X1: 3, X2: 3, Y1: 0, Y2: 0, ActiveFrom: 3}),
+			start:  &Cell{X: 0, Y: 0},
+			finish: &Cell{X: 6, Y: 0},
+			want:   "(0,0) (1,0) (3,0) (6,0)",
+		},
+		{
+			name: "moving wall",
+			grid: NewGrid(3, 5,
+				Obstacle{X1: 2, X2: 2, Y1: 0, Y2: 1, ActiveFor: 1, Period: 2},
+				Obstacle{X1: 2, X2: 2, Y1: 1, Y2: 2, ActiveFrom: 1, ActiveFor: 1, Period: 2},
+			),
+			start:  &Cell{X: 0, Y: 1},
+			finish: &Cell{X: 4, Y: 1},
+			flight: true,
+			// the hopper runs along the lower row while the wall is up, and leaves it as the wall moves down
+			want: "(0,1) (1,2) (2,2) (4,1)",
+		},
+		{
+			name:   "obstacle showing up on the start",
+			grid:   NewGrid(1, 5, Obstacle{X1: 0, X2: 0, Y1: 0, Y2: 0, ActiveFrom: 1, ActiveFor: 1}),
+			start:  &Cell{X: 0, Y: 0},
+			finish: &Cell{X: 4, Y: 0},
+			flight: true,
+			// the hopper leaves the start as the obstacle shows up on it
+			want: "(0,0) (1,0) (2,0) (4,0)",
+		},
+		{
+			name:     "gate closed forever",
+			grid:     NewGrid(1, 7, Obstacle{X1: 3, X2: 3, Y1: 0, Y2: 0, ActiveFrom: 2}),
+			start:    &Cell{X: 0, Y: 0},
+			finish:   &Cell{X: 6, Y: 0},
+			flight:   true,
+			unsolved: true,
+		},
+	}
+	for _, test := range tests {
+		rules := Rules{FlightCollision: test.flight}
+
+		pathfinders := map[string]Pathfinder{
+			"astar": NewGridPathfinder(test.grid, VelocityDistance, rules),
+			"bfs":   NewBreadthFirstPathfinder(test.grid, rules),
+		}
+		for name, pf := range pathfinders {
+			t.Run(test.name+"/"+name, func(t *testing.T) {
+				got, err := pf.FindPath(context.Background(), []*Cell{test.start}, NewLandingGoal(test.finish))
+				assert.NoError(t, err)
+
+				if test.unsolved {
+					assert.Nil(t, got)
+					return
+				}
+				assert.Equal(t, test.want, racePositions(got))
+			})
+		}
+	}
+}
+
+// TestGridPathfinder_FindPath_TimedReference checks the number of hops of the races
+// against the exhaustive enumeration of the races on random tracks with timed obstacles,
+// which checks the obstacles at the real time of each hop rather than at the folded one.
+func TestGridPathfinder_FindPath_TimedReference(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 300; i++ {
+		rows, cols := 1+rnd.Intn(4), 1+rnd.Intn(4)
+
+		var obstacles []Obstacle
+		for n := rnd.Intn(5); n > 0; n-- {
+			x, y := rnd.Intn(cols), rnd.Intn(rows)
+			period := rnd.Intn(4)
+			obstacles = append(obstacles, Obstacle{
+				X1: x, X2: x + rnd.Intn(2), Y1: y, Y2: y + rnd.Intn(2),
+				ActiveFrom: rnd.Intn(4), ActiveFor: 1 + rnd.Intn(max(period, 3)), Period: period,
+			})
+		}
+
+		grid := NewGrid(rows, cols, obstacles...)
+		starts, finish := randomCells(rnd, grid, 1), randomCells(rnd, grid, 1)
+		if len(starts) == 0 || len(finish) == 0 {
+			continue
+		}
+		goal := NewLandingGoal(finish...)
+
+		want, err := NewBreadthFirstPathfinder(grid, Rules{}).FindPath(context.Background(), starts, goal)
+		assert.NoError(t, err)
+
+		got, err := NewGridPathfinder(grid, VelocityDistance, Rules{}).FindPath(context.Background(), starts, goal)
+		assert.NoError(t, err)
+		assert.Equal(t, want.Len(), got.Len(), "obstacles %v, start %v, finish %v", obstacles, starts, finish)
+
+		// no race is shorter than the found one, and the found one is possible
+		hops := 5
+		if want != nil {
+			hops = want.Len()
+		}
+		if hops > 5 {
+			continue
+		}
+
+		start := grid.GetCell(starts[0].X, starts[0].Y)
+		for h := 0; h < hops; h++ {
+			assert.False(t, enumerateTimedRaces(grid, start, 0, goal, h), "obstacles %v, start %v, finish %v", obstacles, starts, finish)
+		}
+		if want != nil {
+			assert.True(t, enumerateTimedRaces(grid, start, 0, goal, hops), "obstacles %v, start %v, finish %v", obstacles, starts, finish)
+		}
+	}
+}
+
+// enumerateTimedRaces reports whether there is a race from the given cell at the given time
+// finishing with exactly the given number of hops by trying every sequence of hops.
+func enumerateTimedRaces(grid *Grid, cell *Cell, time int, finish Goal, hops int) bool {
+	if hops == 0 {
+		return (Rules{}).Finishes(cell, finish)
+	}
+
+	for _, a := range (Rules{}).Accelerations() {
+		speed := Velocity{X: cell.Speed.X + a.X, Y: cell.Speed.Y + a.Y}
+		if speed.X < -3 || speed.X > 3 || speed.Y < -3 || speed.Y > 3 || speed == (Velocity{}) {
+			continue
+		}
+
+		next := &Cell{X: cell.X + speed.X, Y: cell.Y + speed.Y, Speed: speed}
+		if grid.IsAvailableAt(next.X, next.Y, time+1) && enumerateTimedRaces(grid, next, time+1, finish, hops-1) {
+			return true
+		}
+	}
+
+	return false
+}